@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+func consumersListCmd(_ *flag.FlagSet) commandFunc {
+	return func(ctx context.Context, c *cli, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		consumers, err := c.js.ListConsumers(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tFILTER\tPENDING\tACK PENDING\tREDELIVERED\tWAITING")
+		for _, info := range consumers {
+			filter := info.Config.FilterSubject
+			if len(info.Config.FilterSubjects) > 0 {
+				filter = strings.Join(info.Config.FilterSubjects, ",")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\n", info.Name, filter,
+				info.NumPending, info.NumAckPending, info.NumRedelivered, info.NumWaiting)
+		}
+		return w.Flush()
+	}
+}
+
+func consumersInfoCmd(_ *flag.FlagSet) commandFunc {
+	return func(_ context.Context, c *cli, args []string) error {
+		if len(args) != 2 {
+			return errUsage
+		}
+
+		info, err := c.js.ConsumerInfo(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		return c.printJSON(info)
+	}
+}
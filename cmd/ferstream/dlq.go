@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/kumparan/ferstream"
+	"github.com/nats-io/nats.go"
+)
+
+func dlqRedriveCmd(fs *flag.FlagSet) commandFunc {
+	subject := fs.String("subject", "", "only move the messages of the original subject")
+	rate := fs.Int("rate", 0, "max messages moved per second, 0 is unlimited")
+	dryRun := fs.Bool("dry-run", false, "list the messages without moving them")
+
+	return func(ctx context.Context, c *cli, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		var filter ferstream.RedriveFilter
+		if *subject != "" {
+			filter = func(msg *nats.Msg) bool {
+				return msg.Header.Get(ferstream.HeaderOriginalSubject) == *subject
+			}
+		}
+
+		var opts []ferstream.RedriveOption
+		if *dryRun {
+			opts = append(opts, ferstream.WithRedriveDryRun())
+		}
+
+		// the messages processed before an error are printed along with it
+		result, err := ferstream.Redrive(ctx, c.js, args[0], filter, *rate, opts...)
+		if printErr := printRedriveResult(c, result); printErr != nil && err == nil {
+			err = printErr
+		}
+		return err
+	}
+}
+
+func printRedriveResult(c *cli, result *ferstream.RedriveResult) error {
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RESULT\tSEQUENCE\tORIGINAL SUBJECT\tLAST ERROR")
+	for _, group := range []struct {
+		name    string
+		entries []ferstream.RedriveEntry
+	}{
+		{"moved", result.Moved},
+		{"skipped", result.Skipped},
+		{"failed", result.Failed},
+	} {
+		for _, entry := range group.entries {
+			lastError := entry.LastError
+			if entry.Err != nil {
+				lastError = entry.Err.Error()
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", group.name, entry.Sequence, entry.OriginalSubject, lastError)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "\nmoved: %d, skipped: %d, failed: %d\n", len(result.Moved), len(result.Skipped), len(result.Failed))
+	return nil
+}
@@ -0,0 +1,140 @@
+// Command ferstream inspects and operates the streams of the services using ferstream
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/kumparan/ferstream"
+	"github.com/nats-io/nats.go"
+)
+
+type (
+	// cli the state shared by the commands
+	cli struct {
+		js  ferstream.JetStream
+		out io.Writer
+	}
+
+	commandFunc func(ctx context.Context, c *cli, args []string) error
+
+	// command setup defines the command flags and returns the command
+	command struct {
+		name  string
+		args  string
+		help  string
+		setup func(fs *flag.FlagSet) commandFunc
+	}
+)
+
+var errUsage = errors.New("invalid usage")
+
+var commands = []command{
+	{name: "streams ls", help: "list the streams", setup: streamsListCmd},
+	{name: "streams info", args: "<stream>", help: "show the config and state of a stream", setup: streamsInfoCmd},
+	{name: "streams purge", args: "<stream>", help: "delete the messages of a stream", setup: streamsPurgeCmd},
+	{name: "consumers ls", args: "<stream>", help: "list the consumers of a stream", setup: consumersListCmd},
+	{name: "consumers info", args: "<stream> <consumer>", help: "show the config and state of a consumer", setup: consumersInfoCmd},
+	{name: "tail", args: "<subject>", help: "print the messages of a subject, decoding NatsEventMessage and NatsEventAuditLogMessage", setup: tailCmd},
+	{name: "publish", args: "<subject>", help: "publish a NatsEventMessage", setup: publishCmd},
+	{name: "dlq redrive", args: "<dead letter stream>", help: "move dead letter messages back to their original subject", setup: dlqRedriveCmd},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("ferstream", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	server := fs.String("server", envOrDefault("NATS_URL", nats.DefaultURL), "NATS server URL, defaults to $NATS_URL")
+	creds := fs.String("creds", "", "NATS user credentials file")
+	fs.Usage = func() { printUsage(fs) }
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cmd, cmdArgs := findCommand(fs.Args())
+	if cmd == nil {
+		printUsage(fs)
+		return 2
+	}
+
+	cmdFlags := flag.NewFlagSet("ferstream "+cmd.name, flag.ContinueOnError)
+	cmdFlags.SetOutput(stderr)
+	cmdFlags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: ferstream %s [flags] %s\n\n%s\n\n", cmd.name, cmd.args, cmd.help)
+		cmdFlags.PrintDefaults()
+	}
+	runCmd := cmd.setup(cmdFlags)
+	if err := cmdFlags.Parse(cmdArgs); err != nil {
+		return 2
+	}
+
+	// keep the command output readable, only the errors of the library are logged
+	ferstream.SetLogConfig(ferstream.LogConfig{
+		Logger: ferstream.NewSlogLogger(slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelError}))),
+	})
+
+	natsOpts := []nats.Option{nats.Name("ferstream-cli")}
+	if *creds != "" {
+		natsOpts = append(natsOpts, nats.UserCredentials(*creds))
+	}
+	js, err := ferstream.NewNATSConnection(*server, nil, natsOpts...)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to connect to %s: %s\n", *server, err)
+		return 1
+	}
+	defer ferstream.SafeClose(js)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	err = runCmd(ctx, &cli{js: js, out: stdout}, cmdFlags.Args())
+	switch {
+	case errors.Is(err, errUsage):
+		cmdFlags.Usage()
+		return 2
+	case err != nil:
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// findCommand returns the command named by the leading args and the remaining args
+func findCommand(args []string) (*command, []string) {
+	for i := range commands {
+		words := strings.Fields(commands[i].name)
+		if len(args) >= len(words) && slices.Equal(args[:len(words)], words) {
+			return &commands[i], args[len(words):]
+		}
+	}
+	return nil, nil
+}
+
+func printUsage(fs *flag.FlagSet) {
+	out := fs.Output()
+	fmt.Fprintf(out, "Usage: ferstream [flags] <command> [command flags] [args]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-40s %s\n", cmd.name+" "+cmd.args, cmd.help)
+	}
+	fmt.Fprintf(out, "\nFlags:\n")
+	fs.PrintDefaults()
+}
+
+func envOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
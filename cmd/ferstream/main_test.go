@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kumparan/ferstream"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindCommand(t *testing.T) {
+	cmd, args := findCommand([]string{"streams", "info", "STREAM"})
+	require.NotNil(t, cmd)
+	assert.Equal(t, "streams info", cmd.name)
+	assert.Equal(t, []string{"STREAM"}, args)
+
+	cmd, args = findCommand([]string{"tail", "-all", "STREAM.*"})
+	require.NotNil(t, cmd)
+	assert.Equal(t, "tail", cmd.name)
+	assert.Equal(t, []string{"-all", "STREAM.*"}, args)
+
+	cmd, _ = findCommand([]string{"streams"})
+	assert.Nil(t, cmd)
+}
+
+func TestRun_Usage(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	assert.Equal(t, 2, run([]string{"unknown"}, &bytes.Buffer{}, stderr))
+	assert.Contains(t, stderr.String(), "dlq redrive")
+}
+
+func TestPublishFlags_buildMessage(t *testing.T) {
+	f := &publishFlags{id: 1, userID: 2, tenantID: 3, body: `{"name":"ferstream"}`, eventType: "test.created"}
+	natsMsg, err := f.buildMessage("STREAM.A")
+	require.NoError(t, err)
+	assert.Equal(t, "STREAM.A", natsMsg.Subject)
+	assert.Equal(t, "test.created", natsMsg.Header.Get(ferstream.HeaderEventType))
+
+	msg, err := ferstream.ParseNatsEventMessageFromBytes(natsMsg.Data)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), msg.NatsEvent.GetID())
+	assert.Equal(t, int64(3), msg.NatsEvent.GetTenantID())
+	assert.Equal(t, `{"name":"ferstream"}`, msg.Body)
+
+	_, err = (&publishFlags{id: 1, userID: 2, body: "not a json"}).buildMessage("STREAM.A")
+	assert.Error(t, err)
+
+	_, err = (&publishFlags{id: 1}).buildMessage("STREAM.A")
+	assert.Error(t, err, "user id is required")
+}
+
+func TestFormatMessage(t *testing.T) {
+	t.Run("NatsEventMessage", func(t *testing.T) {
+		natsMsg, err := (&publishFlags{id: 1, userID: 2, body: `{"name":"ferstream"}`}).buildMessage("STREAM.A")
+		require.NoError(t, err)
+
+		out := formatMessage(natsMsg, false)
+		assert.Contains(t, out, "--- STREAM.A\n")
+		assert.Contains(t, out, "NatsEventMessage id=1")
+		assert.Contains(t, out, "body: {\n  \"name\": \"ferstream\"\n}")
+	})
+
+	t.Run("NatsEventAuditLogMessage", func(t *testing.T) {
+		data, err := (&ferstream.NatsEventAuditLogMessage{
+			ServiceName:   "ferstream",
+			UserID:        2,
+			AuditableType: "user",
+			AuditableID:   "1",
+			Action:        "update",
+			CreatedAt:     time.Now(),
+		}).Build()
+		require.NoError(t, err)
+
+		out := formatMessage(&nats.Msg{Subject: "AUDIT.A", Data: data}, false)
+		assert.Contains(t, out, "NatsEventAuditLogMessage service=ferstream user_id=2 action=update auditable=user/1")
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		natsMsg, err := (&publishFlags{id: 1, userID: 2, body: `{"name":"ferstream"}`}).buildMessage("STREAM.A")
+		require.NoError(t, err)
+
+		out := formatMessage(natsMsg, true)
+		assert.True(t, strings.HasSuffix(out, "\n"+string(natsMsg.Data)))
+		assert.NotContains(t, out, "NatsEventMessage id=1")
+	})
+
+	t.Run("undecodable data", func(t *testing.T) {
+		out := formatMessage(&nats.Msg{Subject: "STREAM.A", Data: []byte("plain text")}, false)
+		assert.Equal(t, "--- STREAM.A\nplain text", out)
+	})
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/kumparan/ferstream"
+	"github.com/nats-io/nats.go"
+)
+
+type publishFlags struct {
+	id       int64
+	idString string
+	userID   int64
+	tenantID int64
+	time     string
+	body     string
+	oldBody  string
+
+	eventType     string
+	schemaVersion string
+}
+
+func publishCmd(fs *flag.FlagSet) commandFunc {
+	f := &publishFlags{}
+	fs.Int64Var(&f.id, "id", 0, "event id")
+	fs.StringVar(&f.idString, "id-string", "", "event id string, used when the id is not a number")
+	fs.Int64Var(&f.userID, "user-id", 0, "event user id")
+	fs.Int64Var(&f.tenantID, "tenant-id", 0, "event tenant id")
+	fs.StringVar(&f.time, "time", "", "event time in RFC3339Nano, defaults to now")
+	fs.StringVar(&f.body, "body", "", "JSON body")
+	fs.StringVar(&f.oldBody, "old-body", "", "JSON old body")
+	fs.StringVar(&f.eventType, "event-type", "", "event type header")
+	fs.StringVar(&f.schemaVersion, "schema-version", "", "schema version header")
+
+	return func(_ context.Context, c *cli, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		msg, err := f.buildMessage(args[0])
+		if err != nil {
+			return err
+		}
+
+		ack, err := c.js.PublishMsg(msg)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "published to stream %s #%d\n", ack.Stream, ack.Sequence)
+		return nil
+	}
+}
+
+// buildMessage builds a NatsEventMessage from the flags, the bodies are used as is so they must be JSON
+func (f *publishFlags) buildMessage(subject string) (*nats.Msg, error) {
+	for _, body := range []string{f.body, f.oldBody} {
+		if body != "" && !json.Valid([]byte(body)) {
+			return nil, errors.New("body and old-body must be JSON")
+		}
+	}
+
+	msg := ferstream.NewNatsEventMessage().WithEvent(&ferstream.NatsEvent{
+		ID:       f.id,
+		IDString: f.idString,
+		UserID:   f.userID,
+		TenantID: f.tenantID,
+		Time:     f.time,
+	})
+	msg.Body = f.body
+	msg.OldBody = f.oldBody
+	if f.eventType != "" {
+		msg.WithEventType(f.eventType)
+	}
+	if f.schemaVersion != "" {
+		msg.WithSchemaVersion(f.schemaVersion)
+	}
+	return msg.BuildMsg(subject)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nats-io/nats.go"
+)
+
+func streamsListCmd(_ *flag.FlagSet) commandFunc {
+	return func(ctx context.Context, c *cli, _ []string) error {
+		streams, err := c.js.ListStreams(ctx, "")
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSUBJECTS\tMESSAGES\tBYTES\tCONSUMERS\tLAST SEQUENCE")
+		for _, info := range streams {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\n", info.Config.Name, strings.Join(info.Config.Subjects, ","),
+				info.State.Msgs, info.State.Bytes, info.State.Consumers, info.State.LastSeq)
+		}
+		return w.Flush()
+	}
+}
+
+func streamsInfoCmd(_ *flag.FlagSet) commandFunc {
+	return func(_ context.Context, c *cli, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		info, err := c.js.StreamInfo(args[0])
+		if err != nil {
+			return err
+		}
+		return c.printJSON(info)
+	}
+}
+
+func streamsPurgeCmd(fs *flag.FlagSet) commandFunc {
+	subject := fs.String("subject", "", "only purge the messages of the subject")
+	keep := fs.Uint64("keep", 0, "keep the latest messages")
+	seq := fs.Uint64("seq", 0, "purge the messages up to, not including, the sequence")
+	force := fs.Bool("force", false, "confirm the purge")
+
+	return func(_ context.Context, c *cli, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+		if !*force {
+			return errors.New("purging deletes messages, rerun with -force to confirm")
+		}
+
+		err := c.js.PurgeStream(args[0], &nats.StreamPurgeRequest{Subject: *subject, Keep: *keep, Sequence: *seq})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "stream %s purged\n", args[0])
+		return nil
+	}
+}
+
+func (c *cli) printJSON(v any) error {
+	encoder := json.NewEncoder(c.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
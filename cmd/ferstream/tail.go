@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/kumparan/ferstream"
+	"github.com/nats-io/nats.go"
+)
+
+func tailCmd(fs *flag.FlagSet) commandFunc {
+	all := fs.Bool("all", false, "print every stored message first")
+	last := fs.Bool("last", false, "print the last stored message of each subject first")
+	raw := fs.Bool("raw", false, "print the message data without decoding")
+
+	return func(ctx context.Context, c *cli, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		deliverOpt := nats.DeliverNew()
+		switch {
+		case *all:
+			deliverOpt = nats.DeliverAll()
+		case *last:
+			deliverOpt = nats.DeliverLastPerSubject()
+		}
+
+		sub, err := c.js.Subscribe(args[0], func(msg *nats.Msg) {
+			fmt.Fprintln(c.out, formatMessage(msg, *raw))
+		}, nats.OrderedConsumer(), deliverOpt)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = sub.Unsubscribe() }()
+
+		<-ctx.Done()
+		return nil
+	}
+}
+
+// formatMessage formats a message for reading, NatsEventMessage and NatsEventAuditLogMessage are decoded
+func formatMessage(msg *nats.Msg, raw bool) string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "--- %s", msg.Subject)
+	if meta, err := msg.Metadata(); err == nil {
+		fmt.Fprintf(sb, " #%d %s", meta.Sequence.Stream, meta.Timestamp.Format(ferstream.NatsEventTimeFormat))
+	}
+	sb.WriteString("\n")
+
+	for _, key := range slices.Sorted(maps.Keys(msg.Header)) {
+		fmt.Fprintf(sb, "%s: %s\n", key, strings.Join(msg.Header[key], ", "))
+	}
+
+	if raw {
+		sb.Write(msg.Data)
+		return sb.String()
+	}
+	sb.WriteString(decodeMessage(msg.Data))
+	return sb.String()
+}
+
+// decodeMessage decodes the data by the fields it has, the data is returned as is when it is neither message type
+func decodeMessage(data []byte) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return string(data)
+	}
+
+	if hasField(fields, "NatsEvent") {
+		if msg, err := ferstream.ParseNatsEventMessageFromBytes(data); err == nil {
+			return formatNatsEventMessage(msg)
+		}
+	}
+	if hasField(fields, "auditable_type") {
+		if msg, err := ferstream.ParseNatsEventAuditLogMessageFromBytes(data); err == nil {
+			return formatAuditLogMessage(msg)
+		}
+	}
+	return indentJSON(string(data))
+}
+
+func formatNatsEventMessage(msg *ferstream.NatsEventMessage) string {
+	sb := &strings.Builder{}
+	event := msg.NatsEvent
+	fmt.Fprintf(sb, "NatsEventMessage id=%d id_string=%q user_id=%d tenant_id=%d time=%s\n",
+		event.GetID(), event.GetIDString(), event.GetUserID(), event.GetTenantID(), event.GetTime())
+	if msg.Body != "" {
+		fmt.Fprintf(sb, "body: %s\n", indentJSON(msg.Body))
+	}
+	if msg.OldBody != "" {
+		fmt.Fprintf(sb, "old_body: %s\n", indentJSON(msg.OldBody))
+	}
+	if len(msg.Request) > 0 {
+		fmt.Fprintf(sb, "request: %d bytes\n", len(msg.Request))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func formatAuditLogMessage(msg *ferstream.NatsEventAuditLogMessage) string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "NatsEventAuditLogMessage service=%s user_id=%d action=%s auditable=%s/%s created_at=%s\n",
+		msg.ServiceName, msg.UserID, msg.Action, msg.AuditableType, msg.AuditableID, msg.CreatedAt.Format(ferstream.NatsEventTimeFormat))
+	for _, field := range []struct{ name, value string }{
+		{"audited_changes", msg.AuditedChanges},
+		{"old_data", msg.OldData},
+		{"new_data", msg.NewData},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(sb, "%s: %s\n", field.name, indentJSON(field.value))
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func hasField(fields map[string]json.RawMessage, name string) bool {
+	value, ok := fields[name]
+	return ok && string(value) != "null"
+}
+
+// indentJSON indents the value when it is JSON
+func indentJSON(value string) string {
+	buf := &bytes.Buffer{}
+	if err := json.Indent(buf, []byte(value), "", "  "); err != nil {
+		return value
+	}
+	return buf.String()
+}
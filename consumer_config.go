@@ -0,0 +1,98 @@
+package ferstream
+
+import (
+	"slices"
+
+	"github.com/nats-io/nats.go"
+)
+
+// consumerField compares a field of the live consumer config with the desired one
+type consumerField struct {
+	name    string
+	changed func(live, desired *nats.ConsumerConfig) bool
+}
+
+// immutableConsumerFields the fields the server refuses to update
+var immutableConsumerFields = []consumerField{
+	{"DeliverPolicy", func(l, d *nats.ConsumerConfig) bool { return l.DeliverPolicy != d.DeliverPolicy }},
+	{"OptStartSeq", func(l, d *nats.ConsumerConfig) bool { return l.OptStartSeq != d.OptStartSeq }},
+	{"OptStartTime", func(l, d *nats.ConsumerConfig) bool { return !equalTime(l, d) }},
+	{"AckPolicy", func(l, d *nats.ConsumerConfig) bool { return l.AckPolicy != d.AckPolicy }},
+	{"ReplayPolicy", func(l, d *nats.ConsumerConfig) bool { return l.ReplayPolicy != d.ReplayPolicy }},
+	{"Heartbeat", func(l, d *nats.ConsumerConfig) bool { return l.Heartbeat != d.Heartbeat }},
+	{"FlowControl", func(l, d *nats.ConsumerConfig) bool { return l.FlowControl != d.FlowControl }},
+	{"DeliverSubject", func(l, d *nats.ConsumerConfig) bool { return (l.DeliverSubject == "") != (d.DeliverSubject == "") }},
+	{"MaxWaiting", func(l, d *nats.ConsumerConfig) bool { return d.MaxWaiting != 0 && l.MaxWaiting != d.MaxWaiting }},
+}
+
+// mutableConsumerFields the fields which can be updated, a zero limit is defaulted by the server so it is not compared
+var mutableConsumerFields = []consumerField{
+	{"Description", func(l, d *nats.ConsumerConfig) bool { return l.Description != d.Description }},
+	// the server sets AckWait to the first BackOff
+	{"AckWait", func(l, d *nats.ConsumerConfig) bool {
+		return d.AckWait != 0 && len(d.BackOff) == 0 && l.AckWait != d.AckWait
+	}},
+	{"MaxDeliver", func(l, d *nats.ConsumerConfig) bool { return d.MaxDeliver != 0 && l.MaxDeliver != d.MaxDeliver }},
+	{"BackOff", func(l, d *nats.ConsumerConfig) bool { return !slices.Equal(l.BackOff, d.BackOff) }},
+	{"FilterSubject", func(l, d *nats.ConsumerConfig) bool { return l.FilterSubject != d.FilterSubject }},
+	{"FilterSubjects", func(l, d *nats.ConsumerConfig) bool { return !slices.Equal(l.FilterSubjects, d.FilterSubjects) }},
+	{"RateLimit", func(l, d *nats.ConsumerConfig) bool { return l.RateLimit != d.RateLimit }},
+	{"SampleFrequency", func(l, d *nats.ConsumerConfig) bool { return l.SampleFrequency != d.SampleFrequency }},
+	{"MaxAckPending", func(l, d *nats.ConsumerConfig) bool {
+		return d.MaxAckPending != 0 && l.MaxAckPending != d.MaxAckPending
+	}},
+	{"HeadersOnly", func(l, d *nats.ConsumerConfig) bool { return l.HeadersOnly != d.HeadersOnly }},
+	{"MaxRequestBatch", func(l, d *nats.ConsumerConfig) bool { return l.MaxRequestBatch != d.MaxRequestBatch }},
+	{"MaxRequestExpires", func(l, d *nats.ConsumerConfig) bool { return l.MaxRequestExpires != d.MaxRequestExpires }},
+	{"MaxRequestMaxBytes", func(l, d *nats.ConsumerConfig) bool { return l.MaxRequestMaxBytes != d.MaxRequestMaxBytes }},
+	{"DeliverSubject", func(l, d *nats.ConsumerConfig) bool { return l.DeliverSubject != d.DeliverSubject }},
+	{"DeliverGroup", func(l, d *nats.ConsumerConfig) bool { return l.DeliverGroup != d.DeliverGroup }},
+	{"InactiveThreshold", func(l, d *nats.ConsumerConfig) bool {
+		return d.InactiveThreshold != 0 && l.InactiveThreshold != d.InactiveThreshold
+	}},
+	{"Replicas", func(l, d *nats.ConsumerConfig) bool { return d.Replicas != 0 && l.Replicas != d.Replicas }},
+	{"MemoryStorage", func(l, d *nats.ConsumerConfig) bool { return l.MemoryStorage != d.MemoryStorage }},
+	{"Metadata", func(l, d *nats.ConsumerConfig) bool { return !containsMetadata(l.Metadata, d.Metadata) }},
+}
+
+func immutableConsumerChanges(live, desired *nats.ConsumerConfig) []string {
+	return changedConsumerFields(immutableConsumerFields, live, desired)
+}
+
+func mutableConsumerChanges(live, desired *nats.ConsumerConfig) []string {
+	return changedConsumerFields(mutableConsumerFields, live, desired)
+}
+
+func changedConsumerFields(fields []consumerField, live, desired *nats.ConsumerConfig) (changed []string) {
+	for _, field := range fields {
+		if field.changed(live, desired) {
+			changed = append(changed, field.name)
+		}
+	}
+	return changed
+}
+
+func consumerName(cfg *nats.ConsumerConfig) string {
+	if cfg.Durable != "" {
+		return cfg.Durable
+	}
+	return cfg.Name
+}
+
+func equalTime(live, desired *nats.ConsumerConfig) bool {
+	if live.OptStartTime == nil || desired.OptStartTime == nil {
+		return live.OptStartTime == desired.OptStartTime
+	}
+	return live.OptStartTime.Equal(*desired.OptStartTime)
+}
+
+// containsMetadata returns true when every desired entry is in the live metadata,
+// the server adds its own entries so the live metadata is never equal to the desired one
+func containsMetadata(live, desired map[string]string) bool {
+	for key, value := range desired {
+		if liveValue, ok := live[key]; !ok || liveValue != value {
+			return false
+		}
+	}
+	return true
+}
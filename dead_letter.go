@@ -0,0 +1,130 @@
+package ferstream
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultDeadLetterSubjectPrefix default prefix of dead letter subject
+const DefaultDeadLetterSubjectPrefix = "DLQ"
+
+// dead letter message headers
+const (
+	// HeaderOriginalSubject subject of the message before it is dead lettered
+	HeaderOriginalSubject = "Ferstream-Original-Subject"
+	// HeaderOriginalStream stream of the message before it is dead lettered
+	HeaderOriginalStream = "Ferstream-Original-Stream"
+	// HeaderOriginalStreamSequence stream sequence of the message before it is dead lettered
+	HeaderOriginalStreamSequence = "Ferstream-Original-Stream-Sequence"
+	// HeaderDeliveryCount number of deliveries of the message before it is dead lettered
+	HeaderDeliveryCount = "Ferstream-Delivery-Count"
+	// HeaderLastError error string of the last failed attempt
+	HeaderLastError = "Ferstream-Last-Error"
+	// HeaderFailedAt time when the message is dead lettered, formatted with NatsEventTimeFormat
+	HeaderFailedAt = "Ferstream-Failed-At"
+)
+
+// deadLetterHeaders headers added when the message is dead lettered
+var deadLetterHeaders = []string{
+	HeaderOriginalSubject,
+	HeaderOriginalStream,
+	HeaderOriginalStreamSequence,
+	HeaderDeliveryCount,
+	HeaderLastError,
+	HeaderFailedAt,
+}
+
+type (
+	// DeadLetterConfig :nodoc:
+	DeadLetterConfig struct {
+		// SubjectPrefix the message is republished to <SubjectPrefix>.<original subject>, default to DefaultDeadLetterSubjectPrefix
+		SubjectPrefix string
+		// Stream when set, the stream is created through AddStream before the first message is dead lettered.
+		// The stream subjects should cover the dead letter subjects, e.g. "DLQ.>"
+		Stream *nats.StreamConfig
+	}
+
+	deadLetter struct {
+		js  JetStream
+		cfg DeadLetterConfig
+
+		mu            sync.Mutex
+		streamCreated bool
+	}
+)
+
+// WithDeadLetter republishes the raw message data to the dead letter subject when the message is given up,
+// before handing it over to the error handler. When the republish fails, the message is nak-ed so it is not lost.
+func WithDeadLetter(js JetStream, cfg DeadLetterConfig) HandlerOption {
+	if cfg.SubjectPrefix == "" {
+		cfg.SubjectPrefix = DefaultDeadLetterSubjectPrefix
+	}
+
+	return func(o *handlerOptions) {
+		o.deadLetter = &deadLetter{js: js, cfg: cfg}
+	}
+}
+
+// DeadLetterSubject returns the dead letter subject of the given subject
+func (c DeadLetterConfig) DeadLetterSubject(subject string) string {
+	prefix := c.SubjectPrefix
+	if prefix == "" {
+		prefix = DefaultDeadLetterSubjectPrefix
+	}
+	return prefix + "." + subject
+}
+
+func (d *deadLetter) publish(msg *nats.Msg, meta *nats.MsgMetadata, cause error) error {
+	err := d.ensureStream()
+	if err != nil {
+		return err
+	}
+
+	dlqMsg := nats.NewMsg(d.cfg.DeadLetterSubject(msg.Subject))
+	dlqMsg.Data = msg.Data
+	for key, values := range msg.Header {
+		// nats headers (e.g. Nats-Msg-Id, Nats-Expected-Stream) are meant for the original publish only
+		if strings.HasPrefix(key, "Nats-") {
+			continue
+		}
+		dlqMsg.Header[key] = values
+	}
+	dlqMsg.Header.Set(HeaderOriginalSubject, msg.Subject)
+	dlqMsg.Header.Set(HeaderFailedAt, time.Now().Format(NatsEventTimeFormat))
+	if cause != nil {
+		dlqMsg.Header.Set(HeaderLastError, cause.Error())
+	}
+	if meta != nil {
+		dlqMsg.Header.Set(HeaderOriginalStream, meta.Stream)
+		dlqMsg.Header.Set(HeaderOriginalStreamSequence, strconv.FormatUint(meta.Sequence.Stream, 10))
+		dlqMsg.Header.Set(HeaderDeliveryCount, strconv.FormatUint(meta.NumDelivered, 10))
+	}
+
+	_, err = d.js.PublishMsg(dlqMsg)
+	return err
+}
+
+func (d *deadLetter) ensureStream() error {
+	if d.cfg.Stream == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.streamCreated {
+		return nil
+	}
+
+	_, err := d.js.AddStream(d.cfg.Stream)
+	if err != nil {
+		return err
+	}
+
+	d.streamCreated = true
+	return nil
+}
@@ -0,0 +1,83 @@
+package ferstream
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDeadLetter(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	addTestStream(t, n, &nats.StreamConfig{
+		Name:     "STREAM_NAME_DEAD_LETTER",
+		Subjects: []string{"STREAM_NAME_DEAD_LETTER.*"},
+		Storage:  nats.MemoryStorage,
+	})
+
+	subject := "STREAM_NAME_DEAD_LETTER.TEST"
+	dlqCfg := DeadLetterConfig{
+		Stream: &nats.StreamConfig{
+			Name:     "DLQ_STREAM_NAME_DEAD_LETTER",
+			Subjects: []string{"DLQ.STREAM_NAME_DEAD_LETTER.>"},
+			Storage:  nats.MemoryStorage,
+		},
+	}
+	_ = n.DeleteStream(dlqCfg.Stream.Name)
+	msgHandler := func(_ MessageParser) error {
+		return assert.AnError
+	}
+	errHandlerCh := make(chan MessageParser, 1)
+	errHandler := func(payload MessageParser) error {
+		errHandlerCh <- payload
+		return nil
+	}
+
+	handler := NewNATSMessageHandler(NewNatsEventMessage(), 2, time.Millisecond, msgHandler, errHandler, WithDeadLetter(n, dlqCfg))
+	sub, err := n.Subscribe(subject, handler, nats.Durable("dead_letter"), nats.ManualAck(), nats.DeliverNew())
+	require.NoError(t, err)
+	defer func() { _ = sub.Unsubscribe() }()
+
+	msgBytes := newTestNatsEventMessage(t)
+	ack, err := n.Publish(subject, msgBytes)
+	require.NoError(t, err)
+
+	select {
+	case <-errHandlerCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("error handler is not called")
+	}
+
+	dlqCh := make(chan *nats.Msg, 1)
+	dlqSub, err := n.Subscribe(dlqCfg.DeadLetterSubject(subject), func(msg *nats.Msg) {
+		dlqCh <- msg
+	}, nats.BindStream("DLQ_STREAM_NAME_DEAD_LETTER"), nats.DeliverAll())
+	require.NoError(t, err)
+	defer func() { _ = dlqSub.Unsubscribe() }()
+
+	select {
+	case dlqMsg := <-dlqCh:
+		assert.Equal(t, "DLQ."+subject, dlqMsg.Subject)
+		assert.Equal(t, msgBytes, dlqMsg.Data)
+		assert.Equal(t, subject, dlqMsg.Header.Get(HeaderOriginalSubject))
+		assert.Equal(t, "STREAM_NAME_DEAD_LETTER", dlqMsg.Header.Get(HeaderOriginalStream))
+		assert.Equal(t, strconv.FormatUint(ack.Sequence, 10), dlqMsg.Header.Get(HeaderOriginalStreamSequence))
+		assert.Equal(t, "1", dlqMsg.Header.Get(HeaderDeliveryCount))
+		assert.Equal(t, assert.AnError.Error(), dlqMsg.Header.Get(HeaderLastError))
+		_, err = time.Parse(NatsEventTimeFormat, dlqMsg.Header.Get(HeaderFailedAt))
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message is not dead lettered")
+	}
+}
+
+func TestDeadLetterConfig_DeadLetterSubject(t *testing.T) {
+	assert.Equal(t, "DLQ.FOO.BAR", DeadLetterConfig{}.DeadLetterSubject("FOO.BAR"))
+	assert.Equal(t, "DEAD.FOO.BAR", DeadLetterConfig{SubjectPrefix: "DEAD"}.DeadLetterSubject("FOO.BAR"))
+}
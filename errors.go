@@ -1,6 +1,11 @@
 package ferstream
 
-import "errors"
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
 
 var (
 	// ErrBadUnmarshalResult given when unmarshal result from a message's Data is not as intended
@@ -13,4 +18,143 @@ var (
 	ErrNilMessagePayload = errors.New("ferstreamErr: nil message payload given")
 	// ErrConnectionLost given when no active nats connection
 	ErrConnectionLost = errors.New("ferstreamErr: connection error")
+	// ErrMissingOriginalSubject given when a dead letter message has no original subject header
+	ErrMissingOriginalSubject = errors.New("ferstreamErr: missing original subject header")
+	// ErrImmutableField given when a desired config changes a field which can not be updated on the server
+	ErrImmutableField = errors.New("ferstreamErr: immutable field changed")
+	// ErrDestructiveChange given when a desired config changes a stream destructively
+	ErrDestructiveChange = errors.New("ferstreamErr: destructive change")
+	// ErrShutdownIncomplete given when Shutdown stops waiting before every subscription and publish is done
+	ErrShutdownIncomplete = errors.New("ferstreamErr: shutdown incomplete")
+	// ErrInvalidFetchLoopOption given when FetchLoop is configured with a batch size or a wait which can not be fetched
+	ErrInvalidFetchLoopOption = errors.New("ferstreamErr: invalid fetch loop option")
 )
+
+type (
+	// PermanentError a message handling failure which will never succeed on retry, e.g. a poison message
+	PermanentError struct {
+		Err error
+	}
+
+	// RetryAfterError a transient message handling failure which should be retried after Delay
+	RetryAfterError struct {
+		Err   error
+		Delay time.Duration
+	}
+
+	// ImmutableFieldError the desired config of Name changes Fields which can not be updated, it has to be deleted and recreated instead
+	ImmutableFieldError struct {
+		Name   string
+		Fields []string
+	}
+
+	// DestructiveChangeError the desired config of Name changes Fields destructively, see ReconcileStreamOptions.AllowDestructive
+	DestructiveChangeError struct {
+		Name   string
+		Fields []string
+	}
+
+	// ShutdownError the Subscriptions whose handlers were still running and the PendingPublishes
+	// which were not acked when the context of Shutdown is done, Err is the context error
+	ShutdownError struct {
+		Subscriptions    []string
+		PendingPublishes int
+		Err              error
+	}
+)
+
+// Permanent wraps err as PermanentError, the message is not retried and goes straight to the dead letter or error handler
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// RetryAfter wraps err as RetryAfterError, the next attempt waits for d instead of the retry interval
+func RetryAfter(err error, d time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &RetryAfterError{Err: err, Delay: d}
+}
+
+// IsPermanent returns true when err is or wraps a PermanentError
+func IsPermanent(err error) bool {
+	var permanentErr *PermanentError
+	return errors.As(err, &permanentErr)
+}
+
+// retryAfter returns the delay of RetryAfterError, ok is false when err does not wrap a RetryAfterError
+func retryAfter(err error) (d time.Duration, ok bool) {
+	var retryAfterErr *RetryAfterError
+	if !errors.As(err, &retryAfterErr) {
+		return 0, false
+	}
+	return retryAfterErr.Delay, true
+}
+
+// Error :nodoc:
+func (e *PermanentError) Error() string {
+	return "ferstreamErr: permanent failure: " + e.Err.Error()
+}
+
+// Unwrap :nodoc:
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Error :nodoc:
+func (e *RetryAfterError) Error() string {
+	return "ferstreamErr: retry after " + e.Delay.String() + ": " + e.Err.Error()
+}
+
+// Unwrap :nodoc:
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// Error :nodoc:
+func (e *ImmutableFieldError) Error() string {
+	return "ferstreamErr: " + e.Name + ": can not update " + strings.Join(e.Fields, ", ")
+}
+
+// Unwrap :nodoc:
+func (e *ImmutableFieldError) Unwrap() error {
+	return ErrImmutableField
+}
+
+// Error :nodoc:
+func (e *DestructiveChangeError) Error() string {
+	return "ferstreamErr: " + e.Name + ": destructive change of " + strings.Join(e.Fields, ", ")
+}
+
+// Unwrap :nodoc:
+func (e *DestructiveChangeError) Unwrap() error {
+	return ErrDestructiveChange
+}
+
+// Error :nodoc:
+func (e *ShutdownError) Error() string {
+	msg := "ferstreamErr: shutdown incomplete:"
+	if len(e.Subscriptions) > 0 {
+		msg += " subscriptions " + strings.Join(e.Subscriptions, ", ") + " did not finish;"
+	}
+	if e.PendingPublishes > 0 {
+		msg += " " + strconv.Itoa(e.PendingPublishes) + " async publishes are not acked;"
+	}
+	if e.Err != nil {
+		msg += " " + e.Err.Error()
+	}
+	return strings.TrimSuffix(msg, ";")
+}
+
+// Is :nodoc:
+func (e *ShutdownError) Is(target error) bool {
+	return target == ErrShutdownIncomplete
+}
+
+// Unwrap :nodoc:
+func (e *ShutdownError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,109 @@
+package ferstream
+
+import (
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+)
+
+// event message headers, they let consumers route and filter messages without decoding the body
+const (
+	// HeaderEventType type of the event, e.g. "order.created"
+	HeaderEventType = "Ferstream-Event-Type"
+	// HeaderTenantID tenant id of the event, set by BuildMsg from NatsEvent.TenantID
+	HeaderTenantID = "Ferstream-Tenant-Id"
+	// HeaderSchemaVersion version of the body schema
+	HeaderSchemaVersion = "Ferstream-Schema-Version"
+	// HeaderContentType content type of the message data, set to ContentTypeJSON by BuildMsg
+	HeaderContentType = "Content-Type"
+	// HeaderTraceParent W3C trace context traceparent
+	HeaderTraceParent = "traceparent"
+	// HeaderTraceState W3C trace context tracestate
+	HeaderTraceState = "tracestate"
+)
+
+// ContentTypeJSON content type of NatsEventMessage data
+const ContentTypeJSON = "application/json"
+
+// WithHeader sets the header key to value
+func (n *NatsEventMessage) WithHeader(key, value string) *NatsEventMessage {
+	if n.Header == nil {
+		n.Header = nats.Header{}
+	}
+	n.Header.Set(key, value)
+	return n
+}
+
+// WithEventType :nodoc:
+func (n *NatsEventMessage) WithEventType(eventType string) *NatsEventMessage {
+	return n.WithHeader(HeaderEventType, eventType)
+}
+
+// WithSchemaVersion :nodoc:
+func (n *NatsEventMessage) WithSchemaVersion(version string) *NatsEventMessage {
+	return n.WithHeader(HeaderSchemaVersion, version)
+}
+
+// WithContentType overrides ContentTypeJSON, e.g. with a versioned media type
+func (n *NatsEventMessage) WithContentType(contentType string) *NatsEventMessage {
+	return n.WithHeader(HeaderContentType, contentType)
+}
+
+// WithTraceContext sets the W3C trace context headers, traceState is optional
+func (n *NatsEventMessage) WithTraceContext(traceParent, traceState string) *NatsEventMessage {
+	n.WithHeader(HeaderTraceParent, traceParent)
+	if traceState != "" {
+		n.WithHeader(HeaderTraceState, traceState)
+	}
+	return n
+}
+
+// GetHeader returns the first value of the header key
+func (n *NatsEventMessage) GetHeader(key string) string {
+	if n == nil || n.Header == nil {
+		return ""
+	}
+	return n.Header.Get(key)
+}
+
+// GetEventType :nodoc:
+func (n *NatsEventMessage) GetEventType() string {
+	return n.GetHeader(HeaderEventType)
+}
+
+// GetSchemaVersion :nodoc:
+func (n *NatsEventMessage) GetSchemaVersion() string {
+	return n.GetHeader(HeaderSchemaVersion)
+}
+
+// GetContentType :nodoc:
+func (n *NatsEventMessage) GetContentType() string {
+	return n.GetHeader(HeaderContentType)
+}
+
+// BuildMsg same as Build, but returns a message for PublishMsg carrying the headers.
+// HeaderContentType defaults to ContentTypeJSON and HeaderTenantID is set when the event has a tenant id.
+func (n *NatsEventMessage) BuildMsg(subject string) (*nats.Msg, error) {
+	data, err := n.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	for key, values := range n.Header {
+		msg.Header[key] = append([]string(nil), values...)
+	}
+	if msg.Header.Get(HeaderContentType) == "" {
+		msg.Header.Set(HeaderContentType, ContentTypeJSON)
+	}
+	if tenantID := n.NatsEvent.GetTenantID(); tenantID != 0 && msg.Header.Get(HeaderTenantID) == "" {
+		msg.Header.Set(HeaderTenantID, strconv.FormatInt(tenantID, 10))
+	}
+	return msg, nil
+}
+
+// ParseHeader implements HeaderParser, the header is replaced so nothing leaks from the previous message
+func (n *NatsEventMessage) ParseHeader(header nats.Header) {
+	n.Header = header
+}
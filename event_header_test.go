@@ -0,0 +1,71 @@
+package ferstream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNatsEventMessage_BuildMsg(t *testing.T) {
+	t.Run("headers", func(t *testing.T) {
+		msg := NewNatsEventMessage().
+			WithEvent(&NatsEvent{ID: 111, UserID: 432, TenantID: 666}).
+			WithBody("test").
+			WithEventType("story.published").
+			WithSchemaVersion("2").
+			WithTraceContext("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", "").
+			WithHeader("X-Custom", "custom")
+
+		natsMsg, err := msg.BuildMsg("STORY.PUBLISHED")
+		require.NoError(t, err)
+		assert.Equal(t, "STORY.PUBLISHED", natsMsg.Subject)
+		assert.Equal(t, "story.published", natsMsg.Header.Get(HeaderEventType))
+		assert.Equal(t, "666", natsMsg.Header.Get(HeaderTenantID))
+		assert.Equal(t, "2", natsMsg.Header.Get(HeaderSchemaVersion))
+		assert.Equal(t, ContentTypeJSON, natsMsg.Header.Get(HeaderContentType))
+		assert.Equal(t, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", natsMsg.Header.Get(HeaderTraceParent))
+		assert.Empty(t, natsMsg.Header.Values(HeaderTraceState))
+		assert.Equal(t, "custom", natsMsg.Header.Get("X-Custom"))
+
+		// the headers are not part of the body
+		data, err := msg.Build()
+		require.NoError(t, err)
+		assert.Equal(t, data, natsMsg.Data)
+		assert.NotContains(t, string(natsMsg.Data), "story.published")
+
+		parsed, err := ParseNatsEventMessageFromBytes(natsMsg.Data)
+		require.NoError(t, err)
+		parsed.ParseHeader(natsMsg.Header)
+		assert.Equal(t, "story.published", parsed.GetEventType())
+		assert.Equal(t, "2", parsed.GetSchemaVersion())
+		assert.Equal(t, ContentTypeJSON, parsed.GetContentType())
+	})
+
+	t.Run("without headers", func(t *testing.T) {
+		natsMsg, err := NewNatsEventMessage().WithEvent(&NatsEvent{ID: 111, UserID: 432}).BuildMsg("STORY.PUBLISHED")
+		require.NoError(t, err)
+		assert.Equal(t, ContentTypeJSON, natsMsg.Header.Get(HeaderContentType))
+		assert.Empty(t, natsMsg.Header.Values(HeaderTenantID))
+	})
+
+	t.Run("content type override", func(t *testing.T) {
+		natsMsg, err := NewNatsEventMessage().
+			WithEvent(&NatsEvent{ID: 111, UserID: 432}).
+			WithContentType("application/vnd.story+json").
+			BuildMsg("STORY.PUBLISHED")
+		require.NoError(t, err)
+		assert.Equal(t, "application/vnd.story+json", natsMsg.Header.Get(HeaderContentType))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := NewNatsEventMessage().WithEvent(&NatsEvent{UserID: 432}).BuildMsg("STORY.PUBLISHED")
+		assert.Error(t, err)
+	})
+}
+
+func TestNatsEventMessage_GetHeader(t *testing.T) {
+	var msg *NatsEventMessage
+	assert.Empty(t, msg.GetHeader(HeaderEventType))
+	assert.Empty(t, NewNatsEventMessage().GetEventType())
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/kumparan/go-utils"
 	"github.com/kumparan/tapao"
+	"github.com/nats-io/nats.go"
 	"github.com/pkg/errors"
 	"google.golang.org/protobuf/proto"
 )
@@ -31,6 +32,8 @@ type (
 		OldBody   string `json:"old_body"`
 		Request   []byte `json:"request"`
 		Error     error  `json:"error"`
+		// Header is sent as the NATS message headers by BuildMsg and filled from the delivered message by the message handler
+		Header nats.Header `json:"-"`
 	}
 
 	// NatsEventAuditLogMessage :nodoc:
@@ -55,6 +58,12 @@ type (
 		ToJSONString() (string, error)
 		ToJSONByte() ([]byte, error)
 	}
+
+	// HeaderParser implemented by the payload which reads the message headers,
+	// the message handler calls ParseHeader after ParseFromBytes
+	HeaderParser interface {
+		ParseHeader(header nats.Header)
+	}
 )
 
 // GetID :nodoc:
@@ -206,6 +215,9 @@ func (n *NatsEventMessage) ParseFromBytes(data []byte) (err error) {
 
 // AddSubject :nodoc:
 func (n *NatsEventMessage) AddSubject(subj string) {
+	if n.NatsEvent == nil {
+		n.NatsEvent = &NatsEvent{}
+	}
 	n.NatsEvent.Subject = subj
 }
 
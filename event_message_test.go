@@ -475,3 +475,9 @@ func TestNatsEventAuditLogMessage_ParseNatsEventAuditLogMessageFromBytes(t *test
 
 	assert.Equal(t, msg, parsed)
 }
+
+func TestNatsEventMessage_AddSubject(t *testing.T) {
+	msg := NewNatsEventMessage()
+	msg.AddSubject("SUBJECT")
+	assert.Equal(t, "SUBJECT", msg.NatsEvent.GetSubject())
+}
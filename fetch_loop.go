@@ -0,0 +1,151 @@
+package ferstream
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultFetchBatch          = 10
+	defaultFetchMaxBatch       = 100
+	defaultFetchMaxWait        = 5 * time.Second
+	defaultFetchTargetDuration = 5 * time.Second
+	fetchErrorBackoff          = time.Second
+)
+
+type (
+	// FetchLoopOption optional configuration of FetchLoop
+	FetchLoopOption func(*fetchLoopOptions)
+
+	fetchLoopOptions struct {
+		batch          int
+		maxBatch       int
+		maxWait        time.Duration
+		targetDuration time.Duration
+	}
+)
+
+// WithFetchBatch sets the initial and the max number of messages fetched at once
+func WithFetchBatch(initial, maxBatch int) FetchLoopOption {
+	return func(o *fetchLoopOptions) {
+		o.batch = initial
+		o.maxBatch = maxBatch
+	}
+}
+
+// WithFetchMaxWait sets how long a fetch waits for messages
+func WithFetchMaxWait(d time.Duration) FetchLoopOption {
+	return func(o *fetchLoopOptions) {
+		o.maxWait = d
+	}
+}
+
+// WithFetchTargetDuration sets how long handling a batch should take, the batch size is adapted to the handler latency to meet it.
+// It should be well below the consumer's AckWait, since every message of a batch is delivered at once.
+func WithFetchTargetDuration(d time.Duration) FetchLoopOption {
+	return func(o *fetchLoopOptions) {
+		o.targetDuration = d
+	}
+}
+
+// FetchLoop fetches batches of messages from a pull subscription (see JetStream.PullSubscribe) and handles them one by one with handler,
+// e.g. a handler from NewTypedHandler, so pull consumers go through the same parse, retry and ack pipeline.
+// It runs until ctx is done or the subscription is closed, or returns ErrInvalidFetchLoopOption right away.
+func FetchLoop(ctx context.Context, sub *nats.Subscription, handler nats.MsgHandler, opts ...FetchLoopOption) error {
+	o := &fetchLoopOptions{
+		batch:          defaultFetchBatch,
+		maxBatch:       defaultFetchMaxBatch,
+		maxWait:        defaultFetchMaxWait,
+		targetDuration: defaultFetchTargetDuration,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if err := o.validate(); err != nil {
+		return err
+	}
+
+	batch := o.batch
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		msgs, err := fetch(ctx, sub, batch, o.maxWait)
+		if err != nil {
+			return err
+		}
+
+		start := time.Now()
+		for _, msg := range msgs {
+			handler(msg)
+		}
+		batch = o.nextBatch(batch, len(msgs), time.Since(start))
+	}
+}
+
+// validate returns ErrInvalidFetchLoopOption when every fetch would fail
+func (o *fetchLoopOptions) validate() error {
+	switch {
+	case o.batch < 1:
+		return errors.Wrapf(ErrInvalidFetchLoopOption, "batch %d is less than 1", o.batch)
+	case o.maxBatch < o.batch:
+		return errors.Wrapf(ErrInvalidFetchLoopOption, "max batch %d is less than the batch %d", o.maxBatch, o.batch)
+	case o.maxWait <= 0:
+		return errors.Wrapf(ErrInvalidFetchLoopOption, "max wait %s is not positive", o.maxWait)
+	case o.targetDuration <= 0:
+		return errors.Wrapf(ErrInvalidFetchLoopOption, "target duration %s is not positive", o.targetDuration)
+	}
+	return nil
+}
+
+// fetch fetches a batch of messages, it returns no message and no error on timeout and on transient errors
+func fetch(ctx context.Context, sub *nats.Subscription, batch int, maxWait time.Duration) ([]*nats.Msg, error) {
+	fetchCtx, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+
+	msgs, err := sub.Fetch(batch, nats.Context(fetchCtx))
+	switch {
+	case err == nil:
+		return msgs, nil
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	case errors.Is(err, nats.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return nil, nil
+	case errors.Is(err, nats.ErrBadSubscription), errors.Is(err, nats.ErrConnectionClosed):
+		return nil, err
+	}
+
+	getLogger(nil).log(LogEventSubscription, err, LogFields{"subject": sub.Subject})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(fetchErrorBackoff):
+		return nil, nil
+	}
+}
+
+// nextBatch adapts the batch size to the handler latency, so handling a batch takes about the target duration
+func (o *fetchLoopOptions) nextBatch(batch, handled int, elapsed time.Duration) int {
+	if handled == 0 {
+		return batch
+	}
+
+	latency := elapsed / time.Duration(handled)
+	if latency <= 0 {
+		return o.maxBatch
+	}
+
+	next := int(o.targetDuration / latency)
+	switch {
+	case next < 1:
+		return 1
+	case next > o.maxBatch:
+		return o.maxBatch
+	default:
+		return next
+	}
+}
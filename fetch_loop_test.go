@@ -0,0 +1,79 @@
+package ferstream
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchLoop(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	addTestStream(t, n, &nats.StreamConfig{
+		Name:     "STREAM_NAME_FETCH_LOOP",
+		Subjects: []string{"STREAM_NAME_FETCH_LOOP.*"},
+		Storage:  nats.MemoryStorage,
+	})
+
+	subject := "STREAM_NAME_FETCH_LOOP.TEST"
+	countMsg := 25
+	for i := 0; i < countMsg; i++ {
+		_, err = n.Publish(subject, newTestNatsEventMessage(t))
+		require.NoError(t, err)
+	}
+
+	sub, err := n.PullSubscribe(subject, "fetch_loop")
+	require.NoError(t, err)
+	defer func() { _ = sub.Unsubscribe() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var handled int32
+	handler := NewTypedHandler(NewNatsEventMessage, func(_ context.Context, msg *NatsEventMessage) error {
+		assert.Equal(t, subject, msg.NatsEvent.GetSubject())
+		if atomic.AddInt32(&handled, 1) == int32(countMsg) {
+			cancel()
+		}
+		return nil
+	})
+
+	err = FetchLoop(ctx, sub, handler, WithFetchBatch(5, 10), WithFetchMaxWait(100*time.Millisecond))
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, int32(countMsg), atomic.LoadInt32(&handled))
+
+	// every message is acked
+	assert.Eventually(t, func() bool {
+		info, err := sub.ConsumerInfo()
+		return err == nil && info.NumAckPending == 0 && info.NumPending == 0
+	}, time.Second, 10*time.Millisecond)
+	t.Run("invalid options", func(t *testing.T) {
+		for _, opt := range []FetchLoopOption{
+			WithFetchBatch(0, 10),
+			WithFetchBatch(-1, 10),
+			WithFetchBatch(10, 5),
+			WithFetchMaxWait(0),
+			WithFetchTargetDuration(-time.Second),
+		} {
+			err := FetchLoop(context.Background(), sub, handler, opt)
+			assert.ErrorIs(t, err, ErrInvalidFetchLoopOption)
+		}
+	})
+}
+
+func TestFetchLoopOptions_nextBatch(t *testing.T) {
+	o := &fetchLoopOptions{maxBatch: 100, targetDuration: time.Second}
+
+	assert.Equal(t, 10, o.nextBatch(10, 0, 0), "keep batch when nothing handled")
+	assert.Equal(t, 100, o.nextBatch(10, 10, 0), "max batch on zero latency")
+	assert.Equal(t, 100, o.nextBatch(10, 10, 10*time.Millisecond), "capped at max batch")
+	assert.Equal(t, 20, o.nextBatch(10, 10, 500*time.Millisecond))
+	assert.Equal(t, 1, o.nextBatch(10, 10, 20*time.Second), "at least one message")
+}
@@ -0,0 +1,189 @@
+package ferstream
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultHealthCheckTimeout the timeout of the JetStream account request of Health when ctx has no deadline
+const DefaultHealthCheckTimeout = time.Second
+
+// HealthProbe which check of the HealthReport decides the status code of NewHealthHandler
+type HealthProbe int
+
+// health probes
+const (
+	// HealthProbeLiveness fails once the connection is closed, a reconnecting connection is still alive
+	HealthProbeLiveness HealthProbe = iota
+	// HealthProbeReadiness fails when the connection is not connected, JetStream is not reachable
+	// or a subscription is a slow consumer
+	HealthProbeReadiness
+)
+
+type (
+	// HealthChecker implemented by the JetStream returned by NewNATSConnection
+	HealthChecker interface {
+		Health(ctx context.Context) *HealthReport
+	}
+
+	// HealthReport :nodoc:
+	HealthReport struct {
+		Connected          bool                 `json:"connected"`
+		ConnectionStatus   string               `json:"connection_status"`
+		ServerURL          string               `json:"server_url,omitempty"`
+		JetStreamReachable bool                 `json:"jetstream_reachable"`
+		JetStreamError     string               `json:"jetstream_error,omitempty"`
+		Subscriptions      []SubscriptionHealth `json:"subscriptions"`
+	}
+
+	// SubscriptionHealth the client side state of a subscription created by Subscribe, QueueSubscribe or PullSubscribe,
+	// or of a JetStreamV2 Consume, whose pending counters are left empty
+	SubscriptionHealth struct {
+		Subject string `json:"subject"`
+		Queue   string `json:"queue,omitempty"`
+		Durable string `json:"durable,omitempty"`
+		// PendingMsgs and PendingBytes are delivered by the server but not yet handled
+		PendingMsgs  int `json:"pending_msgs"`
+		PendingBytes int `json:"pending_bytes"`
+		// Dropped the number of messages dropped since the subscription is created because its pending limits were reached
+		Dropped int `json:"dropped"`
+		// SlowConsumer the pending messages currently reach the pending limits, new messages are dropped
+		SlowConsumer bool `json:"slow_consumer"`
+		// LastSuccessAt the last time a message is handled by a message handler, nil when none is handled yet
+		LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+		// SinceLastSuccess the time since LastSuccessAt, in nanoseconds in JSON
+		SinceLastSuccess time.Duration `json:"since_last_success,omitempty"`
+	}
+)
+
+// NewHealthHandler serves the HealthReport of js in JSON, with 200 OK when the probe passes
+// and 503 Service Unavailable otherwise, e.g. as the liveness and the readiness endpoints of Kubernetes
+func NewHealthHandler(js JetStream, probe HealthProbe) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checker, ok := js.(HealthChecker)
+		if !ok {
+			http.Error(w, "health is not supported by the JetStream", http.StatusNotImplemented)
+			return
+		}
+
+		report := checker.Health(r.Context())
+		status := http.StatusOK
+		if !report.Pass(probe) {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
+
+// Health reports the connection status, the JetStream account reachability and the state of the subscriptions,
+// the account request is bound to ctx, or to DefaultHealthCheckTimeout when ctx has no deadline
+func (j *jsImpl) Health(ctx context.Context) *HealthReport {
+	report := &HealthReport{
+		Connected:        j.isValidConn(),
+		ConnectionStatus: nats.DISCONNECTED.String(),
+		Subscriptions:    j.subs.health(time.Now()),
+	}
+	if j.natsConn != nil {
+		report.ConnectionStatus = j.natsConn.Status().String()
+		report.ServerURL = j.natsConn.ConnectedUrlRedacted()
+	}
+	if !report.Connected {
+		report.JetStreamError = ErrConnectionLost.Error()
+		return report
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultHealthCheckTimeout)
+		defer cancel()
+	}
+	if _, err := j.jsCtx.AccountInfo(nats.Context(ctx)); err != nil {
+		report.JetStreamError = err.Error()
+		return report
+	}
+	report.JetStreamReachable = true
+	return report
+}
+
+// Live the connection is not closed
+func (r *HealthReport) Live() bool {
+	return r.ConnectionStatus != nats.CLOSED.String()
+}
+
+// Ready the connection is connected, JetStream is reachable and no subscription is a slow consumer
+func (r *HealthReport) Ready() bool {
+	if !r.Connected || !r.JetStreamReachable {
+		return false
+	}
+	return !slices.ContainsFunc(r.Subscriptions, func(s SubscriptionHealth) bool {
+		return s.SlowConsumer
+	})
+}
+
+// Pass returns the result of the probe
+func (r *HealthReport) Pass(probe HealthProbe) bool {
+	if probe == HealthProbeReadiness {
+		return r.Ready()
+	}
+	return r.Live()
+}
+
+func (s *subscriptionRegistry) health(now time.Time) []SubscriptionHealth {
+	subs := s.all()
+	consumes := s.allConsumes()
+	health := make([]SubscriptionHealth, 0, len(subs)+len(consumes))
+	for _, tracked := range subs {
+		health = append(health, tracked.health(now))
+	}
+	for _, consume := range consumes {
+		health = append(health, consume.health(now))
+	}
+	slices.SortFunc(health, func(a, b SubscriptionHealth) int {
+		return strings.Compare(a.Subject+" "+a.Queue+" "+a.Durable, b.Subject+" "+b.Queue+" "+b.Durable)
+	})
+	return health
+}
+
+// health the errors of the pending counters are ignored, they only fail once the subscription is closed
+func (t *trackedSubscription) health(now time.Time) SubscriptionHealth {
+	h := SubscriptionHealth{
+		Subject: t.key.subject,
+		Queue:   t.key.queue,
+		Durable: t.key.durable,
+	}
+	h.PendingMsgs, h.PendingBytes, _ = t.sub.Pending()
+	h.Dropped, _ = t.sub.Dropped()
+
+	limitMsgs, limitBytes, _ := t.sub.PendingLimits()
+	h.SlowConsumer = (limitMsgs > 0 && h.PendingMsgs >= limitMsgs) || (limitBytes > 0 && h.PendingBytes >= limitBytes)
+
+	h.setLastSuccess(&t.handlerStats, now)
+	return h
+}
+
+// health a consume has no client side pending counters
+func (c *trackedConsume) health(now time.Time) SubscriptionHealth {
+	h := SubscriptionHealth{
+		Subject: c.subject,
+		Durable: c.consumer,
+	}
+	h.setLastSuccess(&c.handlerStats, now)
+	return h
+}
+
+func (h *SubscriptionHealth) setLastSuccess(stats *handlerStats, now time.Time) {
+	if lastSuccess := stats.lastSuccess.Load(); lastSuccess > 0 {
+		at := time.Unix(0, lastSuccess)
+		h.LastSuccessAt = &at
+		h.SinceLastSuccess = now.Sub(at)
+	}
+}
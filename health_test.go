@@ -0,0 +1,114 @@
+package ferstream
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	_, err = n.AddStream(&nats.StreamConfig{
+		Name:     "STREAM_NAME_HEALTH",
+		Subjects: []string{"STREAM_NAME_HEALTH.*"},
+		Storage:  nats.FileStorage,
+	})
+	require.NoError(t, err)
+
+	subject := "STREAM_NAME_HEALTH.TEST"
+	msgHandler := func(_ context.Context, _ MessageParser) error {
+		return nil
+	}
+	sub, err := n.QueueSubscribe(subject, "health", NewMessageHandler(NewNatsEventMessage(), msgHandler),
+		nats.Durable("health"), nats.ManualAck(), nats.DeliverNew())
+	require.NoError(t, err)
+
+	checker, ok := n.(HealthChecker)
+	require.True(t, ok)
+
+	report := checker.Health(context.Background())
+	assert.True(t, report.Connected)
+	assert.True(t, report.JetStreamReachable)
+	assert.Equal(t, nats.CONNECTED.String(), report.ConnectionStatus)
+	require.Len(t, report.Subscriptions, 1)
+	assert.Equal(t, SubscriptionHealth{Subject: subject, Queue: "health", Durable: "health"}, report.Subscriptions[0])
+
+	data, err := NewNatsEventMessage().WithEvent(&NatsEvent{ID: 1, UserID: 2}).Build()
+	require.NoError(t, err)
+	_, err = n.Publish(subject, data)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		subs := checker.Health(context.Background()).Subscriptions
+		return len(subs) == 1 && subs[0].LastSuccessAt != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, sub.Unsubscribe())
+	assert.Eventually(t, func() bool {
+		return len(checker.Health(context.Background()).Subscriptions) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestHealth_SlowConsumer(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	nc := n.GetNATSConnection()
+	sub, err := nc.SubscribeSync("HEALTH_SLOW_CONSUMER")
+	require.NoError(t, err)
+	defer func() { _ = sub.Unsubscribe() }()
+	require.NoError(t, sub.SetPendingLimits(1, -1))
+	n.(*jsImpl).subs.track(sub, subscriptionKey{subject: "HEALTH_SLOW_CONSUMER"}, newConsumerLimits(0, 0))
+
+	for range 2 {
+		require.NoError(t, nc.Publish("HEALTH_SLOW_CONSUMER", []byte("{}")))
+	}
+	require.NoError(t, nc.Flush())
+
+	report := n.(HealthChecker).Health(context.Background())
+	require.Len(t, report.Subscriptions, 1)
+	assert.True(t, report.Subscriptions[0].SlowConsumer)
+	assert.Equal(t, 1, report.Subscriptions[0].PendingMsgs)
+	assert.Equal(t, 1, report.Subscriptions[0].Dropped)
+	assert.True(t, report.Live())
+	assert.False(t, report.Ready())
+}
+
+func TestNewHealthHandler(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+
+	serve := func(probe HealthProbe) (int, *HealthReport) {
+		rec := httptest.NewRecorder()
+		NewHealthHandler(n, probe).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		report := &HealthReport{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), report))
+		return rec.Code, report
+	}
+
+	code, report := serve(HealthProbeReadiness)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, report.JetStreamReachable)
+
+	n.GetNATSConnection().Close()
+
+	code, report = serve(HealthProbeLiveness)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, nats.CLOSED.String(), report.ConnectionStatus)
+
+	code, report = serve(HealthProbeReadiness)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.False(t, report.Connected)
+	assert.Equal(t, ErrConnectionLost.Error(), report.JetStreamError)
+}
@@ -1,22 +1,43 @@
 package ferstream
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
-	"github.com/kumparan/go-utils"
 	"github.com/nats-io/nats.go"
-	"github.com/sirupsen/logrus"
+	"github.com/nats-io/nats.go/jetstream"
 )
 
 type (
 	// JetStream :nodoc:
 	JetStream interface {
 		Publish(subject string, value []byte, opts ...nats.PubOpt) (*nats.PubAck, error)
+		PublishMsg(msg *nats.Msg, opts ...nats.PubOpt) (*nats.PubAck, error)
+		PublishMsgWithContext(ctx context.Context, msg *nats.Msg, opts ...nats.PubOpt) (*nats.PubAck, error)
+		PublishAsync(subject string, value []byte, opts ...nats.PubOpt) (nats.PubAckFuture, error)
+		PublishMsgAsync(msg *nats.Msg, opts ...nats.PubOpt) (nats.PubAckFuture, error)
+		PublishAsyncComplete(ctx context.Context) error
 		QueueSubscribe(subj, queue string, cb nats.MsgHandler, opts ...nats.SubOpt) (*nats.Subscription, error)
 		Subscribe(subj string, cb nats.MsgHandler, opts ...nats.SubOpt) (*nats.Subscription, error)
+		PullSubscribe(subj, durable string, opts ...nats.SubOpt) (*nats.Subscription, error)
 		AddStream(cfg *nats.StreamConfig, opts ...nats.JSOpt) (*nats.StreamInfo, error)
+		UpdateStream(cfg *nats.StreamConfig, opts ...nats.JSOpt) (*nats.StreamInfo, error)
+		StreamInfo(streamName string, opts ...nats.JSOpt) (*nats.StreamInfo, error)
+		DeleteStream(streamName string, opts ...nats.JSOpt) error
+		PurgeStream(streamName string, opts ...nats.JSOpt) error
+		ListStreams(ctx context.Context, subject string) ([]*nats.StreamInfo, error)
+		StreamNames(ctx context.Context, subject string) ([]string, error)
 		ConsumerInfo(streamName, consumerName string, opts ...nats.JSOpt) (*nats.ConsumerInfo, error)
+		AddConsumer(streamName string, cfg *nats.ConsumerConfig, opts ...nats.JSOpt) (*nats.ConsumerInfo, error)
+		UpdateConsumer(streamName string, cfg *nats.ConsumerConfig, opts ...nats.JSOpt) (*nats.ConsumerInfo, error)
+		DeleteConsumer(streamName, consumerName string, opts ...nats.JSOpt) error
+		ListConsumers(ctx context.Context, streamName string) ([]*nats.ConsumerInfo, error)
+		EnsureConsumer(streamName string, cfg *nats.ConsumerConfig, opts ...nats.JSOpt) (*nats.ConsumerInfo, error)
+		DeleteMsg(streamName string, seq uint64, opts ...nats.JSOpt) error
 		GetNATSConnection() *nats.Conn
 	}
 
@@ -24,6 +45,13 @@ type (
 	jsImpl struct {
 		natsConn *nats.Conn
 		jsCtx    nats.JetStreamContext
+		// api the jetstream package client, its listers report their errors unlike the ones of jsCtx
+		api     jetstream.JetStream
+		tracing *tracing
+		metrics Metrics
+		subs    *subscriptionRegistry
+		// streams the names of the streams created or updated by the JetStream
+		streams sync.Map
 	}
 
 	// JetStreamRegistrar :nodoc:
@@ -43,8 +71,56 @@ type (
 
 	// MessageHandler :nodoc:
 	MessageHandler func(payload MessageParser) (err error)
+
+	// ContextMessageHandler a MessageHandler which receives a context bound to the message's AckWait deadline
+	ContextMessageHandler func(ctx context.Context, payload MessageParser) (err error)
+
+	// ConnectionOption optional configuration of NewNATSConnectionWithOptions
+	ConnectionOption func(*connectionOptions)
+
+	connectionOptions struct {
+		natsOpts         []nats.Option
+		jetStreamOpts    []nats.JSOpt
+		jetStreamAPI     bool
+		jetStreamAPIOpts []jetstream.JetStreamOpt
+		tracing          *tracing
+		metrics          Metrics
+		logger           *eventLogger
+	}
 )
 
+// WithNATSOptions sets the options of the NATS connection
+func WithNATSOptions(natsOpts ...nats.Option) ConnectionOption {
+	return func(o *connectionOptions) {
+		o.natsOpts = append(o.natsOpts, natsOpts...)
+	}
+}
+
+// WithPublishAsyncMaxPending sets the maximum number of async publishes waiting for their ack,
+// PublishAsync blocks once it is reached until an ack is received, or returns an error after a while
+func WithPublishAsyncMaxPending(maxPending int) ConnectionOption {
+	return func(o *connectionOptions) {
+		o.jetStreamOpts = append(o.jetStreamOpts, nats.PublishAsyncMaxPending(maxPending))
+	}
+}
+
+// WithPublishAsyncErrorHandler sets the callback of the async publishes which are not acked by the server
+func WithPublishAsyncErrorHandler(handler func(msg *nats.Msg, err error)) ConnectionOption {
+	return func(o *connectionOptions) {
+		o.jetStreamOpts = append(o.jetStreamOpts, nats.PublishAsyncErrHandler(func(_ nats.JetStream, msg *nats.Msg, err error) {
+			handler(msg, err)
+		}))
+	}
+}
+
+// WithJetStreamAPI makes the connection a JetStreamV2, backed by the jetstream package in addition to the legacy JetStreamContext
+func WithJetStreamAPI(opts ...jetstream.JetStreamOpt) ConnectionOption {
+	return func(o *connectionOptions) {
+		o.jetStreamAPI = true
+		o.jetStreamAPIOpts = append(o.jetStreamAPIOpts, opts...)
+	}
+}
+
 // GetNATSConnection :nodoc:
 func (j *jsImpl) GetNATSConnection() *nats.Conn {
 	if j == nil {
@@ -54,43 +130,229 @@ func (j *jsImpl) GetNATSConnection() *nats.Conn {
 }
 
 // Publish publish message using JetStream
-func (j *jsImpl) Publish(subject string, value []byte, opts ...nats.PubOpt) (*nats.PubAck, error) {
+func (j *jsImpl) Publish(subject string, value []byte, opts ...nats.PubOpt) (ack *nats.PubAck, err error) {
+	defer j.observePublish(subject, time.Now(), &err)
 	if !j.isValidConn() {
 		return nil, ErrConnectionLost
 	}
 	return j.jsCtx.Publish(subject, value, opts...)
 }
 
-// QueueSubscribe :nodoc:
-func (j *jsImpl) QueueSubscribe(subj, queue string, cb nats.MsgHandler, opts ...nats.SubOpt) (*nats.Subscription, error) {
+// PublishMsg publish message with headers using JetStream
+func (j *jsImpl) PublishMsg(msg *nats.Msg, opts ...nats.PubOpt) (ack *nats.PubAck, err error) {
+	defer j.observePublish(msg.Subject, time.Now(), &err)
 	if !j.isValidConn() {
 		return nil, ErrConnectionLost
 	}
-	return j.jsCtx.QueueSubscribe(subj, queue, cb, opts...)
+	return j.jsCtx.PublishMsg(msg, opts...)
 }
 
-// Subscribe :nodoc:
-func (j *jsImpl) Subscribe(subj string, cb nats.MsgHandler, opts ...nats.SubOpt) (*nats.Subscription, error) {
+// PublishMsgWithContext same as PublishMsg, the trace context of ctx is injected into the message headers,
+// with WithPublishTracing the publish is recorded as a producer span
+func (j *jsImpl) PublishMsgWithContext(ctx context.Context, msg *nats.Msg, opts ...nats.PubOpt) (ack *nats.PubAck, err error) {
+	defer j.observePublish(msg.Subject, time.Now(), &err)
+	if !j.isValidConn() {
+		return nil, ErrConnectionLost
+	}
+
+	span := j.tracing.startPublish(ctx, msg)
+	defer span.End()
+
+	ack, err = j.jsCtx.PublishMsg(msg, opts...)
+	recordError(span, err)
+	return ack, err
+}
+
+// PublishAsync publish message using JetStream without waiting for the ack, the returned future resolves once it is acked.
+// The async publishes are not reported to the metrics, see WithPublishAsyncErrorHandler to handle the failed ones
+func (j *jsImpl) PublishAsync(subject string, value []byte, opts ...nats.PubOpt) (nats.PubAckFuture, error) {
 	if !j.isValidConn() {
 		return nil, ErrConnectionLost
 	}
-	return j.jsCtx.Subscribe(subj, cb, opts...)
+	return j.jsCtx.PublishAsync(subject, value, opts...)
 }
 
-// AddStream add stream
+// PublishMsgAsync same as PublishAsync, with headers
+func (j *jsImpl) PublishMsgAsync(msg *nats.Msg, opts ...nats.PubOpt) (nats.PubAckFuture, error) {
+	if !j.isValidConn() {
+		return nil, ErrConnectionLost
+	}
+	return j.jsCtx.PublishMsgAsync(msg, opts...)
+}
+
+// PublishAsyncComplete waits until every async publish is acked or failed, or until ctx is done
+func (j *jsImpl) PublishAsyncComplete(ctx context.Context) error {
+	select {
+	case <-j.jsCtx.PublishAsyncComplete():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// observePublish reports the publish to the metrics, it is deferred with the address of the named error result
+func (j *jsImpl) observePublish(subject string, start time.Time, err *error) {
+	j.metrics.ObservePublish(subject, time.Since(start), *err)
+}
+
+// QueueSubscribe subscribes, or returns the subscription of subj and queue created before the reconnect while the clients are initialized again
+func (j *jsImpl) QueueSubscribe(subj, queue string, cb nats.MsgHandler, opts ...nats.SubOpt) (*nats.Subscription, error) {
+	return j.subscribe(subscriptionKey{subject: subj, queue: queue}, cb, func(cb nats.MsgHandler) (*nats.Subscription, error) {
+		return j.jsCtx.QueueSubscribe(subj, queue, cb, opts...)
+	})
+}
+
+// Subscribe subscribes, or returns the subscription of subj created before the reconnect while the clients are initialized again
+func (j *jsImpl) Subscribe(subj string, cb nats.MsgHandler, opts ...nats.SubOpt) (*nats.Subscription, error) {
+	return j.subscribe(subscriptionKey{subject: subj}, cb, func(cb nats.MsgHandler) (*nats.Subscription, error) {
+		return j.jsCtx.Subscribe(subj, cb, opts...)
+	})
+}
+
+// PullSubscribe subscribes, or returns the subscription of subj and durable created before the reconnect while the clients are initialized again
+func (j *jsImpl) PullSubscribe(subj, durable string, opts ...nats.SubOpt) (*nats.Subscription, error) {
+	return j.subscribe(subscriptionKey{subject: subj, durable: durable}, nil, func(nats.MsgHandler) (*nats.Subscription, error) {
+		return j.jsCtx.PullSubscribe(subj, durable, opts...)
+	})
+}
+
+// AddStream add stream, or update it when it exists, the changed fields are logged.
+// See ReconcileStream to refuse destructive changes or to plan the changes without applying them.
 func (j *jsImpl) AddStream(cfg *nats.StreamConfig, opts ...nats.JSOpt) (*nats.StreamInfo, error) {
 	if !j.isValidConn() {
 		return nil, ErrConnectionLost
 	}
 
-	streamInfo, _ := j.jsCtx.StreamInfo(cfg.Name)
+	streamInfo, err := j.jsCtx.StreamInfo(cfg.Name)
+	switch {
+	case errors.Is(err, nats.ErrStreamNotFound):
+		return j.rememberStream(j.jsCtx.AddStream(cfg, opts...))
+	case err != nil:
+		return nil, err
+	}
+
+	changes, err := diffStreamConfig(&streamInfo.Config, cfg, ReconcileStreamOptions{AllowDestructive: true})
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return j.rememberStream(streamInfo, nil)
+	}
+
+	logStreamChanges(cfg.Name, changes)
+	return j.rememberStream(j.jsCtx.UpdateStream(cfg))
+}
+
+// UpdateStream :nodoc:
+func (j *jsImpl) UpdateStream(cfg *nats.StreamConfig, opts ...nats.JSOpt) (*nats.StreamInfo, error) {
+	if !j.isValidConn() {
+		return nil, ErrConnectionLost
+	}
+
+	return j.rememberStream(j.jsCtx.UpdateStream(cfg, opts...))
+}
 
-	if streamInfo == nil {
-		return j.jsCtx.AddStream(cfg, opts...)
+// StreamInfo :nodoc:
+func (j *jsImpl) StreamInfo(streamName string, opts ...nats.JSOpt) (*nats.StreamInfo, error) {
+	if !j.isValidConn() {
+		return nil, ErrConnectionLost
 	}
 
-	return j.jsCtx.UpdateStream(cfg)
+	return j.jsCtx.StreamInfo(streamName, opts...)
+}
 
+// DeleteStream delete a stream and all of its messages
+func (j *jsImpl) DeleteStream(streamName string, opts ...nats.JSOpt) error {
+	if !j.isValidConn() {
+		return ErrConnectionLost
+	}
+
+	err := j.jsCtx.DeleteStream(streamName, opts...)
+	if err == nil {
+		j.streams.Delete(streamName)
+	}
+	return err
+}
+
+// PurgeStream delete the messages of a stream,
+// pass a *nats.StreamPurgeRequest as an option to only purge a subject, up to a sequence, or to keep the latest messages
+func (j *jsImpl) PurgeStream(streamName string, opts ...nats.JSOpt) error {
+	if !j.isValidConn() {
+		return ErrConnectionLost
+	}
+
+	return j.jsCtx.PurgeStream(streamName, opts...)
+}
+
+// ListStreams returns the info of every stream, or of the streams of subject when it is not empty.
+// The requests are bound to ctx, or to the default timeout of the jetstream package when ctx has no deadline.
+func (j *jsImpl) ListStreams(ctx context.Context, subject string) ([]*nats.StreamInfo, error) {
+	streams, err := j.streamsInfo(ctx, streamListOpts(subject)...)
+	if err != nil {
+		return nil, err
+	}
+	return legacyInfos[nats.StreamInfo](streams)
+}
+
+// StreamNames returns the name of every stream, or of the streams of subject when it is not empty
+func (j *jsImpl) StreamNames(ctx context.Context, subject string) ([]string, error) {
+	if !j.isValidConn() {
+		return nil, ErrConnectionLost
+	}
+
+	lister := j.api.StreamNames(ctx, streamListOpts(subject)...)
+	var names []string
+	for name := range lister.Name() {
+		names = append(names, name)
+	}
+	if err := lister.Err(); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// streamsInfo lists the streams with the jetstream package, whose lister returns its error
+// instead of closing the channel of the legacy JetStreamContext on a failed request
+func (j *jsImpl) streamsInfo(ctx context.Context, opts ...jetstream.StreamListOpt) ([]*jetstream.StreamInfo, error) {
+	if !j.isValidConn() {
+		return nil, ErrConnectionLost
+	}
+
+	lister := j.api.ListStreams(ctx, opts...)
+	var streams []*jetstream.StreamInfo
+	for info := range lister.Info() {
+		streams = append(streams, info)
+	}
+	if err := lister.Err(); err != nil {
+		return nil, err
+	}
+	return streams, nil
+}
+
+func streamListOpts(subject string) []jetstream.StreamListOpt {
+	if subject == "" {
+		return nil
+	}
+	return []jetstream.StreamListOpt{jetstream.WithStreamListSubject(subject)}
+}
+
+// legacyInfos converts the infos of the jetstream package into the ones of the legacy JetStreamContext,
+// both are the JSON of the JetStream API
+func legacyInfos[T, S any](infos []*S) ([]*T, error) {
+	legacy := make([]*T, 0, len(infos))
+	for _, info := range infos {
+		data, err := json.Marshal(info)
+		if err != nil {
+			return nil, err
+		}
+		converted := new(T)
+		err = json.Unmarshal(data, converted)
+		if err != nil {
+			return nil, err
+		}
+		legacy = append(legacy, converted)
+	}
+	return legacy, nil
 }
 
 // ConsumerInfo :nodoc:
@@ -102,62 +364,105 @@ func (j *jsImpl) ConsumerInfo(streamName, consumerName string, opts ...nats.JSOp
 	return j.jsCtx.ConsumerInfo(streamName, consumerName, opts...)
 }
 
-func (j *jsImpl) isValidConn() (b bool) {
-	return j.natsConn != nil && j.natsConn.IsConnected()
+// AddConsumer :nodoc:
+func (j *jsImpl) AddConsumer(streamName string, cfg *nats.ConsumerConfig, opts ...nats.JSOpt) (*nats.ConsumerInfo, error) {
+	if !j.isValidConn() {
+		return nil, ErrConnectionLost
+	}
+
+	return j.jsCtx.AddConsumer(streamName, cfg, opts...)
 }
 
-// NewNATSMessageHandler a wrapper to standardize how we handle NATS messages.
-// Payload (arg 0) should always be empty when the method is called. The payload data will later parse data from msg.Data.
-func NewNATSMessageHandler(payload MessageParser, retryAttempts int, retryInterval time.Duration, msgHandler MessageHandler, errHandler MessageHandler) nats.MsgHandler {
-	return func(msg *nats.Msg) {
-		logger := logrus.WithField("msg", utils.Dump(msg))
-		defer func(logger *logrus.Entry) {
-			err := msg.Ack()
-			if err != nil {
-				logger.Error(err)
-			}
-		}(logger)
+// UpdateConsumer :nodoc:
+func (j *jsImpl) UpdateConsumer(streamName string, cfg *nats.ConsumerConfig, opts ...nats.JSOpt) (*nats.ConsumerInfo, error) {
+	if !j.isValidConn() {
+		return nil, ErrConnectionLost
+	}
 
-		if msg.Data == nil {
-			logger.Error("message payload is nil")
-			return
-		}
+	return j.jsCtx.UpdateConsumer(streamName, cfg, opts...)
+}
 
-		err := payload.ParseFromBytes(msg.Data)
-		if err != nil {
-			logger.WithField("error-detail", err).Error("unmarshal failed")
-			return
-		}
+// DeleteConsumer :nodoc:
+func (j *jsImpl) DeleteConsumer(streamName, consumerName string, opts ...nats.JSOpt) error {
+	if !j.isValidConn() {
+		return ErrConnectionLost
+	}
 
-		payload.AddSubject(msg.Subject)
-		defer logger.WithField("payload", utils.Dump(payload)).Warn("message payload")
+	return j.jsCtx.DeleteConsumer(streamName, consumerName, opts...)
+}
 
-		retryErr := utils.Retry(retryAttempts, retryInterval, func() error {
-			return msgHandler(payload)
-		})
-		if retryErr == nil {
-			return
-		}
+// ListConsumers returns the info of every consumer of a stream, nats.ErrStreamNotFound when the stream does not exist
+func (j *jsImpl) ListConsumers(ctx context.Context, streamName string) ([]*nats.ConsumerInfo, error) {
+	consumers, err := j.consumersInfo(ctx, streamName)
+	if errors.Is(err, jetstream.ErrStreamNotFound) {
+		return nil, nats.ErrStreamNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return legacyInfos[nats.ConsumerInfo](consumers)
+}
 
-		logger.WithFields(logrus.Fields{
-			"payload": utils.Dump(payload),
-			"cause":   ErrGiveUpProcessingMessagePayload,
-		}).Error(retryErr)
+func (j *jsImpl) consumersInfo(ctx context.Context, streamName string) ([]*jetstream.ConsumerInfo, error) {
+	if !j.isValidConn() {
+		return nil, ErrConnectionLost
+	}
 
-		if errHandler == nil {
-			return
-		}
+	stream, err := j.api.Stream(ctx, streamName)
+	if err != nil {
+		return nil, err
+	}
 
-		// hand over to error handler
-		logrus.WithField("payload", utils.Dump(payload)).Warnf("handling ErrGiveUpProcessingMessagePayload")
-		err = errHandler(payload)
-		if err != nil {
-			logger.WithFields(logrus.Fields{
-				"payload": utils.Dump(payload),
-				"cause":   err.Error(),
-			}).Error(err)
-		}
+	lister := stream.ListConsumers(ctx)
+	var consumers []*jetstream.ConsumerInfo
+	for info := range lister.Info() {
+		consumers = append(consumers, info)
 	}
+	if err := lister.Err(); err != nil {
+		return nil, err
+	}
+	return consumers, nil
+}
+
+// EnsureConsumer creates the consumer, or updates it when the desired config differs from the server's.
+// The consumer is not updated when nothing changed, and an ImmutableFieldError is returned
+// when the desired config changes a field which can not be updated, e.g. DeliverPolicy or AckPolicy.
+// Zero numeric limits such as AckWait or MaxAckPending are left to the server's defaults and are not compared.
+func (j *jsImpl) EnsureConsumer(streamName string, cfg *nats.ConsumerConfig, opts ...nats.JSOpt) (*nats.ConsumerInfo, error) {
+	if !j.isValidConn() {
+		return nil, ErrConnectionLost
+	}
+
+	name := consumerName(cfg)
+	info, err := j.jsCtx.ConsumerInfo(streamName, name, opts...)
+	switch {
+	case errors.Is(err, nats.ErrConsumerNotFound):
+		return j.jsCtx.AddConsumer(streamName, cfg, opts...)
+	case err != nil:
+		return nil, err
+	}
+
+	if fields := immutableConsumerChanges(&info.Config, cfg); len(fields) > 0 {
+		return nil, &ImmutableFieldError{Name: "consumer " + streamName + "." + name, Fields: fields}
+	}
+	if len(mutableConsumerChanges(&info.Config, cfg)) == 0 {
+		return info, nil
+	}
+
+	return j.jsCtx.UpdateConsumer(streamName, cfg, opts...)
+}
+
+// DeleteMsg delete a message from a stream
+func (j *jsImpl) DeleteMsg(streamName string, seq uint64, opts ...nats.JSOpt) error {
+	if !j.isValidConn() {
+		return ErrConnectionLost
+	}
+
+	return j.jsCtx.DeleteMsg(streamName, seq, opts...)
+}
+
+func (j *jsImpl) isValidConn() (b bool) {
+	return j.natsConn != nil && j.natsConn.IsConnected()
 }
 
 // SafeClose :nodoc:
@@ -174,45 +479,84 @@ func SafeClose(js JetStream) {
 	}
 	err := natsConn.Drain()
 	if err != nil {
-		logrus.Errorf("draining connection error. reason: %q\n", err)
-		logrus.Info("force closing...")
+		getLogger(nil).log(LogEventConnection, "draining connection error, force closing", LogFields{"reason": err.Error()})
 		natsConn.Close()
 	}
 }
 
 // NewNATSConnection :nodoc:
 func NewNATSConnection(NATSJSHost string, clients []JetStreamRegistrar, natsOpts ...nats.Option) (JetStream, error) {
+	return NewNATSConnectionWithOptions(NATSJSHost, clients, WithNATSOptions(natsOpts...))
+}
+
+// NewNATSConnectionWithOptions same as NewNATSConnection, with ferstream's connection options, e.g. WithJetStreamAPI
+func NewNATSConnectionWithOptions(NATSJSHost string, clients []JetStreamRegistrar, connOpts ...ConnectionOption) (JetStream, error) {
+	o := &connectionOptions{
+		tracing: noopTracing,
+		metrics: noopMetrics{},
+	}
+	for _, opt := range connOpts {
+		opt(o)
+	}
+
+	lifecycle := &connectionLifecycle{clients: clients, opts: o}
 	opts := []nats.Option{
 		nats.UseOldRequestStyle(),
 		nats.ErrorHandler(func(_ *nats.Conn, _ *nats.Subscription, err error) {
-			logrus.Errorf("NATS got error! reason: %q\n", err)
+			o.log(LogEventConnection, "NATS got error", LogFields{"reason": errString(err)})
 		}),
 		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
-			logrus.Errorf("NATS got disconnected! reason: %q\n", err)
+			o.metrics.ConnectionStateChanged(ConnectionStateDisconnected)
+			o.log(LogEventConnection, "NATS got disconnected", LogFields{"reason": errString(err)})
+			lifecycle.disconnected(err)
 		}),
 		nats.ReconnectHandler(func(nc *nats.Conn) {
-			_, err := initJetStreamClients(nc, clients)
-			if err != nil {
-				logrus.Errorf("NATS failed to reconnect. reason: %q\n", err)
-				return
-			}
-
-			fmt.Printf("NATS got reconnected to %q\n", nc.ConnectedUrl())
+			o.metrics.ConnectionStateChanged(ConnectionStateReconnected)
+			o.log(LogEventReconnected, "NATS got reconnected", LogFields{"url": nc.ConnectedUrl()})
+			lifecycle.reconnected()
 		}),
 		nats.ClosedHandler(func(nc *nats.Conn) {
-			logrus.Errorf("NATS connection closed. reason: %q\n", nc.LastError())
+			o.metrics.ConnectionStateChanged(ConnectionStateClosed)
+			o.log(LogEventConnection, "NATS connection closed", LogFields{"reason": errString(nc.LastError())})
 		}),
 	}
 
-	natsOpts = append(natsOpts, opts...)
+	natsOpts := append(o.natsOpts, opts...)
 
 	nc, err := nats.Connect(NATSJSHost, natsOpts...)
 	if err != nil {
-		logrus.Errorf("NATS failed to connect. reason: %q\n", err)
+		o.log(LogEventConnection, "NATS failed to connect", LogFields{"reason": err.Error()})
+		return nil, err
+	}
+	o.metrics.ConnectionStateChanged(ConnectionStateConnected)
+
+	core, js, err := newJetStream(nc, o)
+	if err != nil {
+		return nil, err
+	}
+
+	err = registerJetStreamClient(js, clients)
+	if err != nil {
+		o.log(LogEventConnection, "failed to register jetstream client", LogFields{"reason": err.Error()})
 		return nil, err
 	}
 
-	return initJetStreamClients(nc, clients)
+	// the JetStream outlives the reconnects, the clients are notified by the lifecycle
+	lifecycle.js.Store(core)
+	return js, nil
+}
+
+// log logs the event with the log config of the connection
+func (o *connectionOptions) log(event LogEvent, msg string, fields LogFields) {
+	getLogger(o.logger).log(event, msg, fields)
+}
+
+// errString returns the error string, which is empty for a nil error, e.g. the reason of a closed connection
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
 }
 
 // registerJetStreamClient provide jetstream instance, stream, and subscription registration
@@ -221,11 +565,16 @@ func registerJetStreamClient(js JetStream, clients []JetStreamRegistrar) error {
 		client.RegisterNATSJetStream(js)
 	}
 
+	return initJetStreamClients(clients)
+}
+
+// initJetStreamClients initializes the streams and the subscriptions of the clients
+func initJetStreamClients(clients []JetStreamRegistrar) error {
 	for _, client := range clients {
 		if streamRegistrar, ok := client.(StreamRegistrar); ok {
 			err := streamRegistrar.InitStream()
 			if err != nil {
-				logrus.WithField("client", fmt.Sprintf("%T", client)).Error(err)
+				getLogger(nil).log(LogEventConnection, err, LogFields{"client": fmt.Sprintf("%T", client)})
 				return err
 			}
 		}
@@ -233,7 +582,7 @@ func registerJetStreamClient(js JetStream, clients []JetStreamRegistrar) error {
 		if subscriber, ok := client.(Subscriber); ok {
 			err := subscriber.SubscribeJetStreamEvent()
 			if err != nil {
-				logrus.WithField("client", fmt.Sprintf("%T", client)).Error(err)
+				getLogger(nil).log(LogEventConnection, err, LogFields{"client": fmt.Sprintf("%T", client)})
 				return err
 			}
 		}
@@ -242,23 +591,35 @@ func registerJetStreamClient(js JetStream, clients []JetStreamRegistrar) error {
 	return nil
 }
 
-func initJetStreamClients(nc *nats.Conn, clients []JetStreamRegistrar) (JetStream, error) {
-	jsCtx, err := nc.JetStream()
+// newJetStream creates the JetStream implementation selected by the connection options,
+// core is the jsImpl it is built on
+func newJetStream(nc *nats.Conn, o *connectionOptions) (core *jsImpl, js JetStream, err error) {
+	jsCtx, err := nc.JetStream(o.jetStreamOpts...)
 	if err != nil {
-		logrus.Errorf("failed to get jetstream context. reason: %q\n", err)
-		return nil, err
+		o.log(LogEventConnection, "failed to get jetstream context", LogFields{"reason": err.Error()})
+		return nil, nil, err
+	}
+
+	jsAPI, err := jetstream.New(nc, o.jetStreamAPIOpts...)
+	if err != nil {
+		o.log(LogEventConnection, "failed to get jetstream client", LogFields{"reason": err.Error()})
+		return nil, nil, err
 	}
 
-	js := &jsImpl{
+	core = &jsImpl{
 		natsConn: nc,
 		jsCtx:    jsCtx,
+		api:      jsAPI,
+		tracing:  o.tracing,
+		metrics:  o.metrics,
+		subs:     newSubscriptionRegistry(),
 	}
-
-	err = registerJetStreamClient(js, clients)
-	if err != nil {
-		logrus.Errorf("failed to register jetstream client. reason %q\n", err)
-		return nil, err
+	if !o.jetStreamAPI {
+		return core, core, nil
 	}
 
-	return js, nil
+	return core, &jsAPIImpl{
+		jsImpl: core,
+		js:     jsAPI,
+	}, nil
 }
@@ -0,0 +1,267 @@
+package ferstream
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type (
+	// JetStreamV2 a JetStream with the jetstream package API as an add-on, returned by NewNATSConnectionWithOptions with WithJetStreamAPI.
+	// It is not a JetStream implementation backed by the jetstream package: only the methods declared here use it,
+	// the JetStream methods such as Subscribe, AddStream and Publish still run on the legacy JetStreamContext, since the jetstream
+	// package has no push subscriptions and takes other config types. Clients move over one call at a time
+	// by asserting the JetStream given to RegisterNATSJetStream, e.g. js.(ferstream.JetStreamV2)
+	JetStreamV2 interface {
+		JetStream
+		PublishContext(ctx context.Context, subject string, value []byte, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+		PublishMsgContext(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+		CreateOrUpdateStream(ctx context.Context, cfg jetstream.StreamConfig) (jetstream.Stream, error)
+		Stream(ctx context.Context, name string) (jetstream.Stream, error)
+		StreamsInfo(ctx context.Context, opts ...jetstream.StreamListOpt) ([]*jetstream.StreamInfo, error)
+		CreateOrUpdateConsumer(ctx context.Context, stream string, cfg jetstream.ConsumerConfig) (jetstream.Consumer, error)
+		Consumer(ctx context.Context, stream, consumer string) (jetstream.Consumer, error)
+		ConsumersInfo(ctx context.Context, stream string) ([]*jetstream.ConsumerInfo, error)
+		OrderedConsumer(ctx context.Context, stream string, cfg jetstream.OrderedConsumerConfig) (jetstream.Consumer, error)
+		Consume(consumer jetstream.Consumer, handler nats.MsgHandler, opts ...jetstream.PullConsumeOpt) (jetstream.ConsumeContext, error)
+		GetJetStream() jetstream.JetStream
+	}
+
+	// jsAPIImpl JetStreamV2 implementation
+	jsAPIImpl struct {
+		*jsImpl
+		js jetstream.JetStream
+	}
+
+	// trackedConsume a Consume of a JetStreamV2, reported by Health and drained by Shutdown like the subscriptions
+	trackedConsume struct {
+		handlerStats
+		consumerLimits
+		stream   string
+		consumer string
+		subject  string
+		cc       jetstream.ConsumeContext
+		// ctx the parent of the message contexts, cancelled once the consume is stopped or drained
+		ctx    context.Context
+		cancel context.CancelFunc
+	}
+
+	// consumeContext the ConsumeContext returned by Consume, it cancels the running message contexts once stopped or drained
+	consumeContext struct {
+		jetstream.ConsumeContext
+		consume *trackedConsume
+	}
+
+	// consumedMsg a message of a Consume adapted into the *nats.Msg given to the handler
+	consumedMsg struct {
+		msg     jetstream.Msg
+		consume *trackedConsume
+	}
+)
+
+// consumedMsgs the consumedMsg of every adapted *nats.Msg while its handler runs, in the Consume callback
+// or in a worker of a WorkerPool. The message handlers find the jetstream.Msg to settle by the message they receive.
+var consumedMsgs sync.Map
+
+// GetJetStream returns the underlying jetstream package client
+func (j *jsAPIImpl) GetJetStream() jetstream.JetStream {
+	if j == nil {
+		return nil
+	}
+	return j.js
+}
+
+// PublishContext publish message using the jetstream package, same as PublishMsgContext
+func (j *jsAPIImpl) PublishContext(ctx context.Context, subject string, value []byte, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	msg := nats.NewMsg(subject)
+	msg.Data = value
+	return j.PublishMsgContext(ctx, msg, opts...)
+}
+
+// PublishMsgContext publish message with headers using the jetstream package,
+// the trace context of ctx is injected into the message headers, see PublishMsgWithContext
+func (j *jsAPIImpl) PublishMsgContext(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (ack *jetstream.PubAck, err error) {
+	defer j.observePublish(msg.Subject, time.Now(), &err)
+	if !j.isValidConn() {
+		return nil, ErrConnectionLost
+	}
+
+	span := j.tracing.startPublish(ctx, msg)
+	defer span.End()
+
+	ack, err = j.js.PublishMsg(ctx, msg, opts...)
+	recordError(span, err)
+	return ack, err
+}
+
+// CreateOrUpdateStream :nodoc:
+func (j *jsAPIImpl) CreateOrUpdateStream(ctx context.Context, cfg jetstream.StreamConfig) (jetstream.Stream, error) {
+	if !j.isValidConn() {
+		return nil, ErrConnectionLost
+	}
+	return j.js.CreateOrUpdateStream(ctx, cfg)
+}
+
+// Stream :nodoc:
+func (j *jsAPIImpl) Stream(ctx context.Context, name string) (jetstream.Stream, error) {
+	if !j.isValidConn() {
+		return nil, ErrConnectionLost
+	}
+	return j.js.Stream(ctx, name)
+}
+
+// StreamsInfo same as ListStreams, with the info of the jetstream package and its list options
+func (j *jsAPIImpl) StreamsInfo(ctx context.Context, opts ...jetstream.StreamListOpt) ([]*jetstream.StreamInfo, error) {
+	return j.streamsInfo(ctx, opts...)
+}
+
+// CreateOrUpdateConsumer :nodoc:
+func (j *jsAPIImpl) CreateOrUpdateConsumer(ctx context.Context, stream string, cfg jetstream.ConsumerConfig) (jetstream.Consumer, error) {
+	if !j.isValidConn() {
+		return nil, ErrConnectionLost
+	}
+	return j.js.CreateOrUpdateConsumer(ctx, stream, cfg)
+}
+
+// Consumer :nodoc:
+func (j *jsAPIImpl) Consumer(ctx context.Context, stream, consumer string) (jetstream.Consumer, error) {
+	if !j.isValidConn() {
+		return nil, ErrConnectionLost
+	}
+	return j.js.Consumer(ctx, stream, consumer)
+}
+
+// ConsumersInfo same as ListConsumers, with the info of the jetstream package
+func (j *jsAPIImpl) ConsumersInfo(ctx context.Context, stream string) ([]*jetstream.ConsumerInfo, error) {
+	return j.consumersInfo(ctx, stream)
+}
+
+// OrderedConsumer :nodoc:
+func (j *jsAPIImpl) OrderedConsumer(ctx context.Context, stream string, cfg jetstream.OrderedConsumerConfig) (jetstream.Consumer, error) {
+	if !j.isValidConn() {
+		return nil, ErrConnectionLost
+	}
+	return j.js.OrderedConsumer(ctx, stream, cfg)
+}
+
+// Consume consumes the messages of consumer with handler, e.g. a handler from NewTypedHandler or WorkerPool.Handle.
+// The handler receives the message as a *nats.Msg which is not bound to a subscription, the ferstream handlers ack, nak
+// and terminate it through its jetstream.Msg until they return, so a plain nats.MsgHandler can not ack it. The message context
+// expires after the consumer's AckWait and is cancelled once the returned ConsumeContext is stopped or drained.
+// The consume is reported by Health and drained by Shutdown.
+func (j *jsAPIImpl) Consume(consumer jetstream.Consumer, handler nats.MsgHandler, opts ...jetstream.PullConsumeOpt) (jetstream.ConsumeContext, error) {
+	if !j.isValidConn() {
+		return nil, ErrConnectionLost
+	}
+
+	consume := newTrackedConsume(consumer.CachedInfo())
+	cc, err := consumer.Consume(func(msg jetstream.Msg) {
+		adapted := consume.adapt(msg)
+		consumed := &consumedMsg{msg: msg, consume: consume}
+		consumedMsgs.Store(adapted, consumed)
+		defer consumedMsgs.CompareAndDelete(adapted, consumed)
+		handler(adapted)
+	}, opts...)
+	if err != nil {
+		consume.cancel()
+		return nil, err
+	}
+
+	consume.cc = cc
+	j.subs.trackConsume(consume)
+	return &consumeContext{ConsumeContext: cc, consume: consume}, nil
+}
+
+func newTrackedConsume(info *jetstream.ConsumerInfo) *trackedConsume {
+	ctx, cancel := context.WithCancel(context.Background())
+	consume := &trackedConsume{
+		consumerLimits: newConsumerLimits(0, 0),
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+	if info == nil {
+		return consume
+	}
+
+	consume.stream = info.Stream
+	consume.consumer = info.Name
+	consume.subject = info.Config.FilterSubject
+	if len(info.Config.FilterSubjects) > 0 {
+		consume.subject = strings.Join(info.Config.FilterSubjects, ",")
+	}
+	consume.consumerLimits = newConsumerLimits(info.Config.AckWait, info.Config.MaxDeliver)
+	return consume
+}
+
+// adapt returns the *nats.Msg of msg given to the handler
+func (*trackedConsume) adapt(msg jetstream.Msg) *nats.Msg {
+	adapted := &nats.Msg{
+		Subject: msg.Subject(),
+		Reply:   msg.Reply(),
+		Header:  msg.Headers(),
+		Data:    msg.Data(),
+	}
+	return adapted
+}
+
+// Stop :nodoc:
+func (c *consumeContext) Stop() {
+	c.consume.cancel()
+	c.ConsumeContext.Stop()
+}
+
+// Drain :nodoc:
+func (c *consumeContext) Drain() {
+	c.consume.cancel()
+	c.ConsumeContext.Drain()
+}
+
+// getConsumedMsg returns the consumedMsg of msg, if it is given by Consume and not settled yet
+func getConsumedMsg(msg *nats.Msg) (*consumedMsg, bool) {
+	m, ok := consumedMsgs.Load(msg)
+	if !ok {
+		return nil, false
+	}
+	return m.(*consumedMsg), true
+}
+
+// Ack :nodoc:
+func (m *consumedMsg) Ack() error {
+	return m.msg.Ack()
+}
+
+// Nak :nodoc:
+func (m *consumedMsg) Nak() error {
+	return m.msg.Nak()
+}
+
+// NakWithDelay :nodoc:
+func (m *consumedMsg) NakWithDelay(delay time.Duration) error {
+	return m.msg.NakWithDelay(delay)
+}
+
+// Term :nodoc:
+func (m *consumedMsg) Term() error {
+	return m.msg.Term()
+}
+
+// metadata the metadata of the jetstream.Msg, as the nats.MsgMetadata of a subscription message
+func (m *consumedMsg) metadata() (*nats.MsgMetadata, error) {
+	meta, err := m.msg.Metadata()
+	if err != nil {
+		return nil, err
+	}
+	return &nats.MsgMetadata{
+		Sequence:     nats.SequencePair{Consumer: meta.Sequence.Consumer, Stream: meta.Sequence.Stream},
+		NumDelivered: meta.NumDelivered,
+		NumPending:   meta.NumPending,
+		Timestamp:    meta.Timestamp,
+		Stream:       meta.Stream,
+		Consumer:     meta.Consumer,
+		Domain:       meta.Domain,
+	}, nil
+}
@@ -0,0 +1,177 @@
+package ferstream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewNATSConnectionWithOptions_WithJetStreamAPI(t *testing.T) {
+	registrar := &sClient{}
+	n, err := NewNATSConnectionWithOptions(defaultURL, []JetStreamRegistrar{registrar}, WithJetStreamAPI())
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	jsV2, ok := registrar.js.(JetStreamV2)
+	require.True(t, ok)
+	assert.Equal(t, n, jsV2)
+	assert.NotNil(t, jsV2.GetJetStream())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	streamName := "STREAM_NAME_JETSTREAM_API"
+	subject := streamName + ".TEST"
+	_ = jsV2.DeleteStream(streamName)
+	_, err = jsV2.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     streamName,
+		Subjects: []string{streamName + ".*"},
+		Storage:  jetstream.MemoryStorage,
+	})
+	require.NoError(t, err)
+
+	// the legacy methods keep working on the same connection
+	_, err = jsV2.Publish(subject, newTestNatsEventMessage(t))
+	require.NoError(t, err)
+	_, err = jsV2.PublishContext(ctx, subject, newTestNatsEventMessage(t))
+	require.NoError(t, err)
+
+	ackWait := 5 * time.Second
+	consumer, err := jsV2.CreateOrUpdateConsumer(ctx, streamName, jetstream.ConsumerConfig{
+		Durable:       "jetstream_api",
+		FilterSubject: subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       ackWait,
+	})
+	require.NoError(t, err)
+
+	streams, err := jsV2.StreamsInfo(ctx, jetstream.WithStreamListSubject(subject))
+	require.NoError(t, err)
+	require.Len(t, streams, 1)
+	assert.Equal(t, streamName, streams[0].Config.Name)
+	consumers, err := jsV2.ConsumersInfo(ctx, streamName)
+	require.NoError(t, err)
+	require.Len(t, consumers, 1)
+	assert.Equal(t, ackWait, consumers[0].Config.AckWait)
+
+	handledCh := make(chan struct{}, 2)
+	handler := NewTypedHandler(NewNatsEventMessage, func(ctx context.Context, msg *NatsEventMessage) error {
+		assert.Equal(t, subject, msg.NatsEvent.GetSubject())
+		deadline, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline)
+		assert.WithinDuration(t, time.Now().Add(ackWait), deadline, time.Second)
+		handledCh <- struct{}{}
+		return nil
+	})
+
+	cc, err := jsV2.Consume(consumer, handler)
+	require.NoError(t, err)
+	defer cc.Stop()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-handledCh:
+		case <-time.After(5 * time.Second):
+			t.Fatal("message is not handled")
+		}
+	}
+
+	assert.Eventually(t, func() bool {
+		info, err := consumer.Info(ctx)
+		return err == nil && info.NumAckPending == 0 && info.NumPending == 0
+	}, time.Second, 10*time.Millisecond)
+
+	t.Run("plain handler", func(t *testing.T) {
+		plainConsumer, err := jsV2.CreateOrUpdateConsumer(ctx, streamName, jetstream.ConsumerConfig{
+			Durable:       "jetstream_api_plain",
+			FilterSubject: streamName + ".PLAIN",
+			AckPolicy:     jetstream.AckExplicitPolicy,
+		})
+		require.NoError(t, err)
+
+		plainCh := make(chan *nats.Msg, 1)
+		plainCC, err := jsV2.Consume(plainConsumer, func(msg *nats.Msg) {
+			plainCh <- msg
+		})
+		require.NoError(t, err)
+		defer func() {
+			plainCC.Stop()
+			<-plainCC.Closed()
+		}()
+
+		_, err = jsV2.Publish(streamName+".PLAIN", newTestNatsEventMessage(t))
+		require.NoError(t, err)
+		select {
+		case msg := <-plainCh:
+			// the message which is never settled is not kept once its handler returns
+			assert.Eventually(t, func() bool {
+				_, ok := getConsumedMsg(msg)
+				return !ok
+			}, time.Second, 10*time.Millisecond)
+		case <-time.After(5 * time.Second):
+			t.Fatal("message is not handled")
+		}
+	})
+
+	t.Run("worker pool", func(t *testing.T) {
+		poolConsumer, err := jsV2.CreateOrUpdateConsumer(ctx, streamName, jetstream.ConsumerConfig{
+			Durable:       "jetstream_api_pool",
+			FilterSubject: streamName + ".POOL",
+			AckPolicy:     jetstream.AckExplicitPolicy,
+		})
+		require.NoError(t, err)
+
+		pool := NewWorkerPool(2, NewTypedHandler(NewNatsEventMessage, func(context.Context, *NatsEventMessage) error {
+			return nil
+		}))
+		poolCC, err := jsV2.Consume(poolConsumer, pool.Handle)
+		require.NoError(t, err)
+		defer func() {
+			poolCC.Stop()
+			<-poolCC.Closed()
+		}()
+
+		for i := 0; i < 5; i++ {
+			_, err = jsV2.Publish(streamName+".POOL", newTestNatsEventMessage(t))
+			require.NoError(t, err)
+		}
+		// the workers ack through the jetstream.Msg after the Consume callback returned
+		assert.Eventually(t, func() bool {
+			info, err := poolConsumer.Info(ctx)
+			return err == nil && info.NumAckPending == 0 && info.NumPending == 0 && info.AckFloor.Stream > 0
+		}, 5*time.Second, 10*time.Millisecond)
+		require.NoError(t, pool.Shutdown(ctx))
+	})
+
+	// the consume is reported by Health and drained by Shutdown
+	var report *HealthReport
+	require.Eventually(t, func() bool {
+		report = jsV2.(HealthChecker).Health(ctx)
+		return len(report.Subscriptions) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, subject, report.Subscriptions[0].Subject)
+	assert.Equal(t, "jetstream_api", report.Subscriptions[0].Durable)
+	assert.NotNil(t, report.Subscriptions[0].LastSuccessAt)
+
+	require.NoError(t, Shutdown(ctx, jsV2))
+	select {
+	case <-cc.Closed():
+	default:
+		t.Fatal("consume is not drained")
+	}
+}
+
+func TestNewNATSConnection_LegacyJetStream(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil, nats.Name("legacy"))
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	_, ok := n.(JetStreamV2)
+	assert.False(t, ok)
+	assert.Equal(t, "legacy", n.GetNATSConnection().Opts.Name)
+}
@@ -1,8 +1,10 @@
 package ferstream
 
 import (
+	"context"
 	"encoding/json"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -31,6 +33,15 @@ func TestMain(t *testing.M) {
 	os.Exit(m)
 }
 
+// addTestStream creates the stream of cfg, deleting the stream of the same name left in the store by a previous run.
+// Use nats.MemoryStorage for streams whose messages are counted, so they start empty on every run.
+func addTestStream(t *testing.T, js JetStream, cfg *nats.StreamConfig) {
+	t.Helper()
+	_ = js.DeleteStream(cfg.Name)
+	_, err := js.AddStream(cfg)
+	require.NoError(t, err)
+}
+
 func TestPublish(t *testing.T) {
 	natsOpts := []nats.Option{
 		nats.ErrorHandler(func(_ *nats.Conn, _ *nats.Subscription, e error) {
@@ -56,6 +67,69 @@ func TestPublish(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestPublishAsync(t *testing.T) {
+	var mu sync.Mutex
+	var failed []error
+	n, err := NewNATSConnectionWithOptions(defaultURL, nil,
+		WithPublishAsyncMaxPending(2),
+		WithPublishAsyncErrorHandler(func(_ *nats.Msg, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			failed = append(failed, err)
+		}))
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	_, err = n.AddStream(&nats.StreamConfig{
+		Name:     "STREAM_NAME_PUBLISH_ASYNC",
+		Subjects: []string{"STREAM_NAME_PUBLISH_ASYNC.*"},
+		Storage:  nats.FileStorage,
+	})
+	require.NoError(t, err)
+
+	var futures []nats.PubAckFuture
+	for range 10 {
+		future, err := n.PublishAsync("STREAM_NAME_PUBLISH_ASYNC.TEST", []byte("test"))
+		require.NoError(t, err)
+		futures = append(futures, future)
+	}
+	msg, err := NewNatsEventMessage().WithEvent(&NatsEvent{ID: 1, UserID: 2}).BuildMsg("STREAM_NAME_PUBLISH_ASYNC.TEST")
+	require.NoError(t, err)
+	future, err := n.PublishMsgAsync(msg)
+	require.NoError(t, err)
+	futures = append(futures, future)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, n.PublishAsyncComplete(ctx))
+	for _, future := range futures {
+		select {
+		case ack := <-future.Ok():
+			assert.Equal(t, "STREAM_NAME_PUBLISH_ASYNC", ack.Stream)
+		default:
+			assert.Fail(t, "the publish is not acked")
+		}
+	}
+
+	t.Run("failed ack", func(t *testing.T) {
+		future, err := n.PublishAsync("PUBLISH_ASYNC_NO_STREAM", []byte("test"))
+		require.NoError(t, err)
+		require.NoError(t, n.PublishAsyncComplete(ctx))
+
+		assert.ErrorIs(t, <-future.Err(), nats.ErrNoResponders)
+		mu.Lock()
+		defer mu.Unlock()
+		require.Len(t, failed, 1)
+		assert.ErrorIs(t, failed[0], nats.ErrNoResponders)
+	})
+
+	t.Run("connection lost", func(t *testing.T) {
+		n.GetNATSConnection().Close()
+		_, err := n.PublishAsync("STREAM_NAME_PUBLISH_ASYNC.TEST", []byte("test"))
+		assert.ErrorIs(t, err, ErrConnectionLost)
+	})
+}
+
 func TestQueueSubscribe(t *testing.T) {
 	t.Run("queue subscribe NatsEventMessage", func(t *testing.T) {
 		n, err := NewNATSConnection(defaultURL, nil)
@@ -410,6 +484,233 @@ func TestConsumerInfo(t *testing.T) {
 	assert.Equal(t, nats.ErrConsumerNotFound, err)
 }
 
+func TestStreamInfo(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	addTestStream(t, n, &nats.StreamConfig{
+		Name:     "STREAM_NAME_INFO",
+		Subjects: []string{"STREAM_NAME_INFO.*"},
+		Storage:  nats.MemoryStorage,
+	})
+
+	_, err = n.Publish("STREAM_NAME_INFO.TEST", []byte("test"))
+	require.NoError(t, err)
+
+	info, err := n.StreamInfo("STREAM_NAME_INFO")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), info.State.Msgs)
+
+	_, err = n.StreamInfo("STREAM_NAME_NOT_FOUND")
+	assert.ErrorIs(t, err, nats.ErrStreamNotFound)
+}
+
+func TestPurgeStream(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	_, err = n.AddStream(&nats.StreamConfig{
+		Name:     "STREAM_NAME_PURGE",
+		Subjects: []string{"STREAM_NAME_PURGE.*"},
+		Storage:  nats.FileStorage,
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = n.Publish("STREAM_NAME_PURGE.A", []byte("test"))
+		require.NoError(t, err)
+		_, err = n.Publish("STREAM_NAME_PURGE.B", []byte("test"))
+		require.NoError(t, err)
+	}
+
+	// keep the latest message of subject A
+	err = n.PurgeStream("STREAM_NAME_PURGE", &nats.StreamPurgeRequest{Subject: "STREAM_NAME_PURGE.A", Keep: 1})
+	require.NoError(t, err)
+
+	info, err := n.StreamInfo("STREAM_NAME_PURGE")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), info.State.Msgs)
+
+	err = n.PurgeStream("STREAM_NAME_PURGE")
+	require.NoError(t, err)
+
+	info, err = n.StreamInfo("STREAM_NAME_PURGE")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), info.State.Msgs)
+}
+
+func TestDeleteStream(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	_, err = n.AddStream(&nats.StreamConfig{
+		Name:     "STREAM_NAME_DELETE",
+		Subjects: []string{"STREAM_NAME_DELETE.*"},
+		Storage:  nats.FileStorage,
+	})
+	require.NoError(t, err)
+
+	err = n.DeleteStream("STREAM_NAME_DELETE")
+	require.NoError(t, err)
+
+	_, err = n.StreamInfo("STREAM_NAME_DELETE")
+	assert.ErrorIs(t, err, nats.ErrStreamNotFound)
+
+	err = n.DeleteStream("STREAM_NAME_DELETE")
+	assert.ErrorIs(t, err, nats.ErrStreamNotFound)
+}
+
+func TestListStreams(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	for _, name := range []string{"STREAM_NAME_LIST_A", "STREAM_NAME_LIST_B"} {
+		_, err = n.AddStream(&nats.StreamConfig{
+			Name:     name,
+			Subjects: []string{name + ".*"},
+			Storage:  nats.FileStorage,
+		})
+		require.NoError(t, err)
+	}
+
+	ctx := context.Background()
+	streams, err := n.ListStreams(ctx, "")
+	require.NoError(t, err)
+	var streamNames []string
+	for _, info := range streams {
+		streamNames = append(streamNames, info.Config.Name)
+	}
+	assert.Contains(t, streamNames, "STREAM_NAME_LIST_A")
+	assert.Contains(t, streamNames, "STREAM_NAME_LIST_B")
+
+	names, err := n.StreamNames(ctx, "STREAM_NAME_LIST_A.*")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"STREAM_NAME_LIST_A"}, names)
+
+	// the errors of the lister are returned instead of a truncated list
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = n.ListStreams(cancelledCtx, "")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = n.StreamNames(cancelledCtx, "")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = n.ListConsumers(ctx, "STREAM_NAME_NOT_FOUND")
+	assert.ErrorIs(t, err, nats.ErrStreamNotFound)
+
+	n.GetNATSConnection().Close()
+	_, err = n.ListStreams(ctx, "")
+	assert.Equal(t, ErrConnectionLost, err)
+}
+
+func TestConsumerManagement(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	_, err = n.AddStream(&nats.StreamConfig{
+		Name:     "STREAM_NAME_CONSUMER",
+		Subjects: []string{"STREAM_NAME_CONSUMER.*"},
+		Storage:  nats.FileStorage,
+	})
+	require.NoError(t, err)
+
+	cfg := &nats.ConsumerConfig{
+		Durable:       "CONSUMER_A",
+		AckPolicy:     nats.AckExplicitPolicy,
+		FilterSubject: "STREAM_NAME_CONSUMER.A",
+	}
+	info, err := n.AddConsumer("STREAM_NAME_CONSUMER", cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "CONSUMER_A", info.Name)
+
+	cfg.AckWait = time.Minute
+	info, err = n.UpdateConsumer("STREAM_NAME_CONSUMER", cfg)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, info.Config.AckWait)
+
+	_, err = n.AddConsumer("STREAM_NAME_CONSUMER", &nats.ConsumerConfig{Durable: "CONSUMER_B", AckPolicy: nats.AckExplicitPolicy})
+	require.NoError(t, err)
+
+	consumers, err := n.ListConsumers(context.Background(), "STREAM_NAME_CONSUMER")
+	require.NoError(t, err)
+	var names []string
+	for _, c := range consumers {
+		names = append(names, c.Name)
+	}
+	assert.ElementsMatch(t, []string{"CONSUMER_A", "CONSUMER_B"}, names)
+
+	err = n.DeleteConsumer("STREAM_NAME_CONSUMER", "CONSUMER_B")
+	require.NoError(t, err)
+
+	_, err = n.ConsumerInfo("STREAM_NAME_CONSUMER", "CONSUMER_B")
+	assert.ErrorIs(t, err, nats.ErrConsumerNotFound)
+}
+
+func TestEnsureConsumer(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	_, err = n.AddStream(&nats.StreamConfig{
+		Name:     "STREAM_NAME_ENSURE_CONSUMER",
+		Subjects: []string{"STREAM_NAME_ENSURE_CONSUMER.*"},
+		Storage:  nats.FileStorage,
+	})
+	require.NoError(t, err)
+
+	cfg := &nats.ConsumerConfig{
+		Durable:        "ENSURE",
+		AckPolicy:      nats.AckExplicitPolicy,
+		FilterSubjects: []string{"STREAM_NAME_ENSURE_CONSUMER.A", "STREAM_NAME_ENSURE_CONSUMER.B"},
+		Metadata:       map[string]string{"team": "ferstream"},
+	}
+
+	t.Run("create", func(t *testing.T) {
+		info, err := n.EnsureConsumer("STREAM_NAME_ENSURE_CONSUMER", cfg)
+		require.NoError(t, err)
+		assert.Equal(t, "ENSURE", info.Name)
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		info, err := n.ConsumerInfo("STREAM_NAME_ENSURE_CONSUMER", "ENSURE")
+		require.NoError(t, err)
+		assert.Empty(t, mutableConsumerChanges(&info.Config, cfg))
+		assert.Empty(t, immutableConsumerChanges(&info.Config, cfg))
+
+		_, err = n.EnsureConsumer("STREAM_NAME_ENSURE_CONSUMER", cfg)
+		require.NoError(t, err)
+	})
+
+	t.Run("update mutable fields", func(t *testing.T) {
+		updateCfg := *cfg
+		updateCfg.AckWait = 2 * time.Minute
+		updateCfg.MaxAckPending = 10
+
+		info, err := n.EnsureConsumer("STREAM_NAME_ENSURE_CONSUMER", &updateCfg)
+		require.NoError(t, err)
+		assert.Equal(t, 2*time.Minute, info.Config.AckWait)
+		assert.Equal(t, 10, info.Config.MaxAckPending)
+	})
+
+	t.Run("immutable fields conflict", func(t *testing.T) {
+		conflictCfg := *cfg
+		conflictCfg.DeliverPolicy = nats.DeliverNewPolicy
+		conflictCfg.AckPolicy = nats.AckAllPolicy
+
+		info, err := n.EnsureConsumer("STREAM_NAME_ENSURE_CONSUMER", &conflictCfg)
+		assert.Nil(t, info)
+		assert.ErrorIs(t, err, ErrImmutableField)
+
+		var immutableErr *ImmutableFieldError
+		require.ErrorAs(t, err, &immutableErr)
+		assert.Equal(t, []string{"DeliverPolicy", "AckPolicy"}, immutableErr.Fields)
+	})
+}
+
 type sClient struct {
 	js               JetStream
 	isInitError      bool
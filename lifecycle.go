@@ -0,0 +1,114 @@
+package ferstream
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+
+	"github.com/nats-io/nats.go"
+)
+
+type (
+	// ReconnectListener a JetStreamRegistrar notified once the connection is reconnected.
+	// The JetStream registered by RegisterNATSJetStream stays the same across reconnects,
+	// the streams and the subscriptions are only initialized again when the server lost them.
+	// nats.go resubscribes the subscriptions on reconnect, subscribing again in OnReconnect duplicates them
+	ReconnectListener interface {
+		OnReconnect() error
+	}
+
+	// DisconnectListener a JetStreamRegistrar notified once the connection is disconnected,
+	// err is nil when the connection is closed
+	DisconnectListener interface {
+		OnDisconnect(err error)
+	}
+
+	// connectionLifecycle notifies the clients of the connection state of their long-lived JetStream
+	connectionLifecycle struct {
+		clients []JetStreamRegistrar
+		opts    *connectionOptions
+		// js is set once the JetStream is created, the handlers of an earlier connection state do nothing
+		js atomic.Pointer[jsImpl]
+	}
+)
+
+func (l *connectionLifecycle) disconnected(err error) {
+	if l.js.Load() == nil {
+		return
+	}
+
+	for _, client := range l.clients {
+		if listener, ok := client.(DisconnectListener); ok {
+			listener.OnDisconnect(err)
+		}
+	}
+}
+
+// reconnected initializes the clients again when the server lost the streams or the consumers, e.g. it restarted
+// without persistent storage, then notifies the clients
+func (l *connectionLifecycle) reconnected() {
+	js := l.js.Load()
+	if js == nil {
+		return
+	}
+	if js.serverLostState() {
+		l.opts.log(LogEventReconnected, "NATS server lost the streams or the consumers, initializing the clients again", nil)
+		err := js.subs.reinitialize(func() error {
+			return initJetStreamClients(l.clients)
+		})
+		if err != nil {
+			l.opts.log(LogEventConnection, "NATS failed to reconnect", LogFields{"reason": err.Error()})
+			return
+		}
+	}
+
+	for _, client := range l.clients {
+		listener, ok := client.(ReconnectListener)
+		if !ok {
+			continue
+		}
+		if err := listener.OnReconnect(); err != nil {
+			l.opts.log(LogEventConnection, "reconnect listener failed", LogFields{"client": fmt.Sprintf("%T", client), "reason": err.Error()})
+		}
+	}
+}
+
+// serverLostState returns true when a stream created or updated by js, or the consumer of a subscription,
+// is not found on the server. The subscriptions of the lost consumers receive nothing anymore, they are unsubscribed
+// so they are created again by SubscribeJetStreamEvent.
+func (j *jsImpl) serverLostState() bool {
+	lost := false
+	for _, name := range j.knownStreams() {
+		if _, err := j.jsCtx.StreamInfo(name); errors.Is(err, nats.ErrStreamNotFound) {
+			lost = true
+		}
+	}
+
+	for _, tracked := range j.subs.all() {
+		_, err := tracked.sub.ConsumerInfo()
+		if errors.Is(err, nats.ErrConsumerNotFound) || errors.Is(err, nats.ErrStreamNotFound) {
+			lost = true
+			_ = tracked.sub.Unsubscribe()
+		}
+	}
+	return lost
+}
+
+// rememberStream records the name of a stream created or updated by js, to check whether the server lost it on reconnect
+func (j *jsImpl) rememberStream(info *nats.StreamInfo, err error) (*nats.StreamInfo, error) {
+	if err == nil && info != nil {
+		j.streams.Store(info.Config.Name, struct{}{})
+	}
+	return info, err
+}
+
+func (j *jsImpl) knownStreams() []string {
+	var names []string
+	j.streams.Range(func(name, _ any) bool {
+		names = append(names, name.(string))
+		return true
+	})
+	slices.Sort(names)
+	return names
+}
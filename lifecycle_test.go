@@ -0,0 +1,124 @@
+package ferstream
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lifecycleClient counts the calls of the client lifecycle
+type lifecycleClient struct {
+	mu    sync.Mutex
+	js    JetStream
+	sub   *nats.Subscription
+	calls map[string]int
+}
+
+func (c *lifecycleClient) record(call string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls[call]++
+}
+
+func (c *lifecycleClient) count(call string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[call]
+}
+
+func (c *lifecycleClient) RegisterNATSJetStream(js JetStream) {
+	c.record("register")
+	c.js = js
+}
+
+func (c *lifecycleClient) InitStream() error {
+	c.record("init stream")
+	_, err := c.js.AddStream(&nats.StreamConfig{
+		Name:     "STREAM_NAME_LIFECYCLE",
+		Subjects: []string{"STREAM_NAME_LIFECYCLE.*"},
+		Storage:  nats.FileStorage,
+	})
+	return err
+}
+
+func (c *lifecycleClient) SubscribeJetStreamEvent() error {
+	c.record("subscribe")
+	sub, err := c.js.Subscribe("STREAM_NAME_LIFECYCLE.TEST", func(msg *nats.Msg) {
+		_ = msg.Ack()
+	}, nats.Durable("lifecycle"), nats.ManualAck(), nats.DeliverNew())
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sub = sub
+	return nil
+}
+
+func (c *lifecycleClient) OnReconnect() error {
+	c.record("reconnect")
+	return nil
+}
+
+func (c *lifecycleClient) OnDisconnect(_ error) {
+	c.record("disconnect")
+}
+
+func (c *lifecycleClient) subscription() *nats.Subscription {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sub
+}
+
+func TestConnectionLifecycle(t *testing.T) {
+	client := &lifecycleClient{calls: map[string]int{}}
+	n, err := NewNATSConnection(defaultURL, []JetStreamRegistrar{client})
+	require.NoError(t, err)
+	defer SafeClose(n)
+	assert.Same(t, n, client.js)
+
+	reconnect := func(t *testing.T, reconnects int) {
+		require.NoError(t, n.GetNATSConnection().ForceReconnect())
+		require.Eventually(t, func() bool {
+			return client.count("reconnect") == reconnects
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, reconnects, client.count("disconnect"))
+		assert.Equal(t, 1, client.count("register"))
+		assert.Same(t, n, client.js)
+	}
+
+	t.Run("server kept the state", func(t *testing.T) {
+		sub := client.subscription()
+		reconnect(t, 1)
+
+		assert.Equal(t, 1, client.count("init stream"))
+		assert.Equal(t, 1, client.count("subscribe"))
+		assert.Same(t, sub, client.subscription())
+		assert.True(t, sub.IsValid())
+	})
+
+	t.Run("server lost the state", func(t *testing.T) {
+		sub := client.subscription()
+		// the stream and its consumers are deleted as if the server restarted without persistent storage
+		require.NoError(t, n.GetNATSConnection().Publish("$JS.API.STREAM.DELETE.STREAM_NAME_LIFECYCLE", nil))
+		require.Eventually(t, func() bool {
+			_, err := n.StreamInfo("STREAM_NAME_LIFECYCLE")
+			return err != nil
+		}, time.Second, 10*time.Millisecond)
+		reconnect(t, 2)
+
+		assert.Equal(t, 2, client.count("init stream"))
+		assert.Equal(t, 2, client.count("subscribe"))
+		assert.False(t, sub.IsValid())
+		assert.NotSame(t, sub, client.subscription())
+		assert.Len(t, n.(SubscriptionLister).Subscriptions(), 1)
+
+		_, err := n.StreamInfo("STREAM_NAME_LIFECYCLE")
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,332 @@
+package ferstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/kumparan/go-utils"
+	"github.com/sirupsen/logrus"
+)
+
+// LogLevel :nodoc:
+type LogLevel int
+
+// log levels, LogLevelOff disables the event
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+	LogLevelOff
+)
+
+// LogEvent what is logged, the level of every event can be changed with LogConfig.Levels
+type LogEvent string
+
+// log events
+const (
+	// LogEventConnection connection errors, disconnects and closes
+	LogEventConnection LogEvent = "connection"
+	// LogEventReconnected the connection is reconnected
+	LogEventReconnected LogEvent = "reconnected"
+	// LogEventMessagePayload every message received by the message handler, with its payload
+	LogEventMessagePayload LogEvent = "message_payload"
+	// LogEventMetadataFailed the JetStream metadata of the message can not be read
+	LogEventMetadataFailed LogEvent = "metadata_failed"
+	// LogEventParseFailed the message can not be parsed into the payload
+	LogEventParseFailed LogEvent = "parse_failed"
+	// LogEventRetry a failed attempt is redelivered by the server
+	LogEventRetry LogEvent = "retry"
+	// LogEventGiveUp the message is given up, with its payload
+	LogEventGiveUp LogEvent = "give_up"
+	// LogEventErrorHandlerFailed the error handler returns an error
+	LogEventErrorHandlerFailed LogEvent = "error_handler_failed"
+	// LogEventDeadLetterFailed the message can not be published to the dead letter subject
+	LogEventDeadLetterFailed LogEvent = "dead_letter_failed"
+	// LogEventAckFailed the ack, nak or term of the message fails
+	LogEventAckFailed LogEvent = "ack_failed"
+	// LogEventExpired the subscription is drained before the message is handled
+	LogEventExpired LogEvent = "expired"
+	// LogEventSubscription errors of FetchLoop
+	LogEventSubscription LogEvent = "subscription"
+	// LogEventStream streams created or updated by AddStream and ReconcileStream
+	LogEventStream LogEvent = "stream"
+	// LogEventRedriveFailed a dead letter message can not be redriven
+	LogEventRedriveFailed LogEvent = "redrive_failed"
+)
+
+// RedactedValue replaces the value of LogConfig.RedactedFields
+const RedactedValue = "[REDACTED]"
+
+// DefaultLogLevels the level of every event without LogConfig.Levels
+var DefaultLogLevels = map[LogEvent]LogLevel{
+	LogEventConnection:         LogLevelError,
+	LogEventReconnected:        LogLevelInfo,
+	LogEventMessagePayload:     LogLevelDebug,
+	LogEventMetadataFailed:     LogLevelWarn,
+	LogEventParseFailed:        LogLevelError,
+	LogEventRetry:              LogLevelWarn,
+	LogEventGiveUp:             LogLevelError,
+	LogEventErrorHandlerFailed: LogLevelError,
+	LogEventDeadLetterFailed:   LogLevelError,
+	LogEventAckFailed:          LogLevelError,
+	LogEventExpired:            LogLevelWarn,
+	LogEventSubscription:       LogLevelError,
+	LogEventStream:             LogLevelInfo,
+	LogEventRedriveFailed:      LogLevelError,
+}
+
+type (
+	// LogFields structured fields of a log entry
+	LogFields map[string]any
+
+	// Logger writes the log entries of ferstream, see NewLogrusLogger and NewSlogLogger
+	Logger interface {
+		// Enabled returns false when the entries of the level are dropped, so the fields do not have to be built
+		Enabled(level LogLevel) bool
+		Log(level LogLevel, msg string, fields LogFields)
+	}
+
+	// LogConfig :nodoc:
+	LogConfig struct {
+		// Logger default to NewLogrusLogger(logrus.StandardLogger())
+		Logger Logger
+		// Levels overrides DefaultLogLevels per event, LogLevelOff disables the event
+		Levels map[LogEvent]LogLevel
+		// RedactedFields keys of the logged payload whose value is replaced with RedactedValue at any depth,
+		// including in the JSON strings of NatsEventMessage.Body and NatsEventMessage.OldBody, e.g. "email"
+		RedactedFields []string
+		// MaxPayloadLength the logged payload is truncated to MaxPayloadLength bytes, 0 is unlimited
+		MaxPayloadLength int
+	}
+
+	// eventLogger logs the events with the levels, redaction and truncation of a LogConfig
+	eventLogger struct {
+		logger           Logger
+		levels           map[LogEvent]LogLevel
+		redactedFields   map[string]bool
+		maxPayloadLength int
+	}
+
+	logrusLogger struct {
+		logger logrus.FieldLogger
+	}
+
+	slogLogger struct {
+		logger *slog.Logger
+	}
+)
+
+var defaultLogger atomic.Pointer[eventLogger]
+
+func init() {
+	SetLogConfig(LogConfig{})
+}
+
+// SetLogConfig sets the log config used without WithLogConfig and WithConnectionLogConfig,
+// and by ReconcileStream, Redrive, FetchLoop, WorkerPool and SafeClose
+func SetLogConfig(cfg LogConfig) {
+	defaultLogger.Store(newEventLogger(cfg))
+}
+
+// WithLogConfig sets the log config of the message handler, default to the one set by SetLogConfig
+func WithLogConfig(cfg LogConfig) HandlerOption {
+	l := newEventLogger(cfg)
+	return func(o *handlerOptions) {
+		o.logger = l
+	}
+}
+
+// WithConnectionLogConfig sets the log config of the connection handlers, default to the one set by SetLogConfig
+func WithConnectionLogConfig(cfg LogConfig) ConnectionOption {
+	l := newEventLogger(cfg)
+	return func(o *connectionOptions) {
+		o.logger = l
+	}
+}
+
+// NewLogrusLogger :nodoc:
+func NewLogrusLogger(logger logrus.FieldLogger) Logger {
+	return &logrusLogger{logger: logger}
+}
+
+// NewSlogLogger :nodoc:
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return &slogLogger{logger: logger}
+}
+
+func newEventLogger(cfg LogConfig) *eventLogger {
+	l := &eventLogger{
+		logger:           cfg.Logger,
+		levels:           maps.Clone(DefaultLogLevels),
+		redactedFields:   make(map[string]bool, len(cfg.RedactedFields)),
+		maxPayloadLength: cfg.MaxPayloadLength,
+	}
+	if l.logger == nil {
+		l.logger = NewLogrusLogger(logrus.StandardLogger())
+	}
+	maps.Copy(l.levels, cfg.Levels)
+	for _, field := range cfg.RedactedFields {
+		l.redactedFields[field] = true
+	}
+	return l
+}
+
+// getLogger returns l, or the logger set by SetLogConfig when l is nil
+func getLogger(l *eventLogger) *eventLogger {
+	if l != nil {
+		return l
+	}
+	return defaultLogger.Load()
+}
+
+// enabled returns false when the event is disabled or dropped by the logger, so the fields do not have to be built
+func (l *eventLogger) enabled(event LogEvent) bool {
+	level := l.level(event)
+	return level < LogLevelOff && l.logger.Enabled(level)
+}
+
+func (l *eventLogger) level(event LogEvent) LogLevel {
+	level, ok := l.levels[event]
+	if !ok {
+		return LogLevelInfo
+	}
+	return level
+}
+
+// log logs msg at the level of the event, an error msg is logged with its string
+func (l *eventLogger) log(event LogEvent, msg any, fields LogFields) {
+	if !l.enabled(event) {
+		return
+	}
+	level := l.level(event)
+
+	entryFields := make(LogFields, len(fields)+1)
+	maps.Copy(entryFields, fields)
+	entryFields["event"] = string(event)
+	l.logger.Log(level, fmt.Sprint(msg), entryFields)
+}
+
+// payload returns the payload dumped into JSON, redacted and truncated
+func (l *eventLogger) payload(payload any) string {
+	dumped := utils.Dump(payload)
+	if len(l.redactedFields) > 0 {
+		dumped = l.redact(dumped)
+	}
+	if l.maxPayloadLength > 0 && len(dumped) > l.maxPayloadLength {
+		truncated := strings.ToValidUTF8(dumped[:l.maxPayloadLength], "")
+		return fmt.Sprintf("%s...(%d bytes truncated)", truncated, len(dumped)-len(truncated))
+	}
+	return dumped
+}
+
+// redact replaces the redacted fields of a JSON document, it is returned as is when it is not JSON
+func (l *eventLogger) redact(document string) string {
+	var value any
+	if json.Unmarshal([]byte(document), &value) != nil {
+		return document
+	}
+	redacted, err := json.Marshal(l.redactValue(value))
+	if err != nil {
+		return document
+	}
+	return string(redacted)
+}
+
+func (l *eventLogger) redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, fieldValue := range v {
+			if l.redactedFields[key] {
+				v[key] = RedactedValue
+				continue
+			}
+			v[key] = l.redactValue(fieldValue)
+		}
+	case []any:
+		for i := range v {
+			v[i] = l.redactValue(v[i])
+		}
+	case string:
+		// NatsEventMessage.Body is a JSON document in a string
+		if strings.HasPrefix(v, "{") || strings.HasPrefix(v, "[") {
+			return l.redact(v)
+		}
+	}
+	return value
+}
+
+// Enabled :nodoc:
+func (l *logrusLogger) Enabled(level LogLevel) bool {
+	var logger *logrus.Logger
+	switch fieldLogger := l.logger.(type) {
+	case *logrus.Logger:
+		logger = fieldLogger
+	case *logrus.Entry:
+		logger = fieldLogger.Logger
+	default:
+		return true
+	}
+	return logger.IsLevelEnabled(logrusLevel(level))
+}
+
+// Log :nodoc:
+func (l *logrusLogger) Log(level LogLevel, msg string, fields LogFields) {
+	entry := l.logger.WithFields(logrus.Fields(fields))
+	switch level {
+	case LogLevelDebug:
+		entry.Debug(msg)
+	case LogLevelInfo:
+		entry.Info(msg)
+	case LogLevelWarn:
+		entry.Warn(msg)
+	default:
+		entry.Error(msg)
+	}
+}
+
+// Enabled :nodoc:
+func (l *slogLogger) Enabled(level LogLevel) bool {
+	return l.logger.Enabled(context.Background(), slogLevel(level))
+}
+
+// Log :nodoc:
+func (l *slogLogger) Log(level LogLevel, msg string, fields LogFields) {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		attrs = append(attrs, slog.Any(key, fields[key]))
+	}
+	l.logger.LogAttrs(context.Background(), slogLevel(level), msg, attrs...)
+}
+
+func logrusLevel(level LogLevel) logrus.Level {
+	switch level {
+	case LogLevelDebug:
+		return logrus.DebugLevel
+	case LogLevelInfo:
+		return logrus.InfoLevel
+	case LogLevelWarn:
+		return logrus.WarnLevel
+	default:
+		return logrus.ErrorLevel
+	}
+}
+
+func slogLevel(level LogLevel) slog.Level {
+	switch level {
+	case LogLevelDebug:
+		return slog.LevelDebug
+	case LogLevelInfo:
+		return slog.LevelInfo
+	case LogLevelWarn:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
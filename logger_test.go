@@ -0,0 +1,191 @@
+package ferstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type logEntry struct {
+	level  LogLevel
+	msg    string
+	fields LogFields
+}
+
+// recordedLogger a Logger which keeps the entries at or above its level
+type recordedLogger struct {
+	mu      sync.Mutex
+	level   LogLevel
+	entries []logEntry
+}
+
+func (l *recordedLogger) Enabled(level LogLevel) bool {
+	return level >= l.level
+}
+
+func (l *recordedLogger) Log(level LogLevel, msg string, fields LogFields) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, logEntry{level: level, msg: msg, fields: fields})
+}
+
+func (l *recordedLogger) find(event LogEvent) (logEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, entry := range l.entries {
+		if entry.fields["event"] == string(event) {
+			return entry, true
+		}
+	}
+	return logEntry{}, false
+}
+
+func TestEventLogger_log(t *testing.T) {
+	logger := &recordedLogger{level: LogLevelInfo}
+	l := newEventLogger(LogConfig{
+		Logger: logger,
+		Levels: map[LogEvent]LogLevel{
+			LogEventReconnected: LogLevelOff,
+			LogEventStream:      LogLevelWarn,
+		},
+	})
+
+	l.log(LogEventReconnected, "disabled", nil)
+	l.log(LogEventMessagePayload, "below the logger level", nil)
+	l.log(LogEventStream, "overridden", LogFields{"stream": "STREAM"})
+	l.log(LogEventConnection, assert.AnError, nil)
+
+	require.Len(t, logger.entries, 2)
+	assert.Equal(t, logEntry{level: LogLevelWarn, msg: "overridden", fields: LogFields{"stream": "STREAM", "event": "stream"}}, logger.entries[0])
+	assert.Equal(t, logEntry{level: LogLevelError, msg: assert.AnError.Error(), fields: LogFields{"event": "connection"}}, logger.entries[1])
+	assert.False(t, l.enabled(LogEventMessagePayload))
+}
+
+func TestEventLogger_payload(t *testing.T) {
+	msg := NewNatsEventMessage().
+		WithEvent(&NatsEvent{ID: 1, UserID: 2}).
+		WithBody(map[string]any{"email": "someone@example.com", "name": "someone"})
+
+	t.Run("redact", func(t *testing.T) {
+		l := newEventLogger(LogConfig{RedactedFields: []string{"email", "old_body"}})
+		payload := l.payload(msg)
+		assert.NotContains(t, payload, "someone@example.com")
+		assert.Contains(t, payload, "someone")
+
+		parsed := &NatsEventMessage{}
+		require.NoError(t, json.Unmarshal([]byte(payload), parsed))
+		assert.JSONEq(t, `{"email": "[REDACTED]", "name": "someone"}`, parsed.Body)
+		assert.Equal(t, RedactedValue, parsed.OldBody)
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		l := newEventLogger(LogConfig{MaxPayloadLength: 10})
+		payload := l.payload(strings.Repeat("é", 10))
+		assert.True(t, strings.HasPrefix(payload, `"éééé...(`), payload)
+		assert.Contains(t, payload, "bytes truncated)")
+	})
+
+	t.Run("as is", func(t *testing.T) {
+		l := newEventLogger(LogConfig{})
+		payload := l.payload(msg)
+		assert.Contains(t, payload, "someone@example.com")
+	})
+}
+
+func TestNewSlogLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewSlogLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	assert.False(t, logger.Enabled(LogLevelInfo))
+	assert.True(t, logger.Enabled(LogLevelError))
+
+	logger.Log(LogLevelError, "failed", LogFields{"subject": "SUBJECT", "event": "give_up"})
+
+	entry := map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "ERROR", entry["level"])
+	assert.Equal(t, "failed", entry["msg"])
+	assert.Equal(t, "SUBJECT", entry["subject"])
+	assert.Equal(t, "give_up", entry["event"])
+}
+
+func TestNewLogrusLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logrusLogger := logrus.New()
+	logrusLogger.SetOutput(buf)
+	logrusLogger.SetFormatter(&logrus.JSONFormatter{})
+	logrusLogger.SetLevel(logrus.WarnLevel)
+
+	logger := NewLogrusLogger(logrusLogger.WithField("service", "test"))
+	assert.False(t, logger.Enabled(LogLevelDebug))
+	assert.True(t, logger.Enabled(LogLevelWarn))
+
+	logger.Log(LogLevelWarn, "retry", LogFields{"subject": "SUBJECT"})
+
+	entry := map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "warning", entry["level"])
+	assert.Equal(t, "retry", entry["msg"])
+	assert.Equal(t, "SUBJECT", entry["subject"])
+	assert.Equal(t, "test", entry["service"])
+}
+
+func TestWithLogConfig(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	_, err = n.AddStream(&nats.StreamConfig{
+		Name:     "STREAM_NAME_LOGGER",
+		Subjects: []string{"STREAM_NAME_LOGGER.*"},
+		Storage:  nats.FileStorage,
+	})
+	require.NoError(t, err)
+
+	subject := "STREAM_NAME_LOGGER.TEST"
+	logger := &recordedLogger{level: LogLevelDebug}
+	msgHandler := func(_ context.Context, _ MessageParser) error {
+		return Permanent(assert.AnError)
+	}
+
+	sub, err := n.Subscribe(subject, NewMessageHandler(NewNatsEventMessage(), msgHandler,
+		WithLogConfig(LogConfig{Logger: logger, RedactedFields: []string{"email"}})),
+		nats.Durable("logger"), nats.ManualAck(), nats.DeliverNew())
+	require.NoError(t, err)
+	defer func() { _ = sub.Unsubscribe() }()
+
+	data, err := NewNatsEventMessage().
+		WithEvent(&NatsEvent{ID: 1, UserID: 2}).
+		WithBody(map[string]any{"email": "someone@example.com"}).
+		Build()
+	require.NoError(t, err)
+	_, err = n.Publish(subject, data)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, ok := logger.find(LogEventMessagePayload)
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+
+	giveUp, ok := logger.find(LogEventGiveUp)
+	require.True(t, ok)
+	assert.Equal(t, LogLevelError, giveUp.level)
+	assert.Contains(t, giveUp.msg, assert.AnError.Error())
+	assert.Equal(t, subject, giveUp.fields["subject"])
+	assert.Equal(t, "STREAM_NAME_LOGGER", giveUp.fields["stream"])
+	assert.Equal(t, uint64(1), giveUp.fields["num-delivered"])
+	assert.NotContains(t, giveUp.fields["payload"], "someone@example.com")
+
+	payload, _ := logger.find(LogEventMessagePayload)
+	assert.Equal(t, LogLevelDebug, payload.level)
+	assert.Contains(t, payload.fields["payload"], RedactedValue)
+}
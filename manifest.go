@@ -0,0 +1,244 @@
+package ferstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// Manifest declares the streams and their consumers, see LoadManifest
+	Manifest struct {
+		Streams []StreamManifest `yaml:"streams"`
+	}
+
+	// StreamManifest declares a stream, durations are Go duration strings e.g. "72h", and an unset limit is unlimited
+	StreamManifest struct {
+		Name              string             `yaml:"name"`
+		Description       string             `yaml:"description"`
+		Subjects          []string           `yaml:"subjects"`
+		Retention         string             `yaml:"retention"` // limits (default), interest or workqueue
+		Storage           string             `yaml:"storage"`   // file (default) or memory
+		Discard           string             `yaml:"discard"`   // old (default) or new
+		Replicas          int                `yaml:"replicas"`
+		MaxAge            string             `yaml:"max_age"`
+		MaxMsgs           int64              `yaml:"max_msgs"`
+		MaxBytes          int64              `yaml:"max_bytes"`
+		MaxMsgsPerSubject int64              `yaml:"max_msgs_per_subject"`
+		MaxMsgSize        int32              `yaml:"max_msg_size"`
+		Duplicates        string             `yaml:"duplicates"`
+		Consumers         []ConsumerManifest `yaml:"consumers"`
+	}
+
+	// ConsumerManifest declares a durable consumer of the stream, it is a pull consumer unless DeliverSubject is set
+	ConsumerManifest struct {
+		Durable        string   `yaml:"durable"`
+		Description    string   `yaml:"description"`
+		FilterSubject  string   `yaml:"filter_subject"`
+		FilterSubjects []string `yaml:"filter_subjects"`
+		DeliverPolicy  string   `yaml:"deliver_policy"` // all (default), last, new or last_per_subject
+		AckPolicy      string   `yaml:"ack_policy"`     // explicit (default), all or none
+		ReplayPolicy   string   `yaml:"replay_policy"`  // instant (default) or original
+		AckWait        string   `yaml:"ack_wait"`
+		MaxDeliver     int      `yaml:"max_deliver"`
+		MaxAckPending  int      `yaml:"max_ack_pending"`
+		BackOff        []string `yaml:"backoff"`
+		DeliverSubject string   `yaml:"deliver_subject"`
+		DeliverGroup   string   `yaml:"deliver_group"`
+	}
+
+	// ManifestStreamRegistrar a StreamRegistrar which applies a Manifest on InitStream,
+	// pass it in the clients of NewNATSConnection
+	ManifestStreamRegistrar struct {
+		manifest *Manifest
+		js       JetStream
+	}
+)
+
+// LoadManifest reads and validates a YAML or JSON manifest file
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read manifest %s", path)
+	}
+	return ParseManifest(data)
+}
+
+// ParseManifest parses and validates a YAML or JSON manifest, unknown fields are rejected
+func ParseManifest(data []byte) (*Manifest, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	m := &Manifest{}
+	err := decoder.Decode(m)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse manifest")
+	}
+
+	err = m.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Validate returns an error when a stream or a consumer can not be converted into its config
+func (m *Manifest) Validate() error {
+	streamNames := make(map[string]bool, len(m.Streams))
+	for i := range m.Streams {
+		stream := &m.Streams[i]
+		if streamNames[stream.Name] {
+			return errors.Errorf("duplicate stream %s", stream.Name)
+		}
+		streamNames[stream.Name] = true
+
+		_, err := stream.StreamConfig()
+		if err != nil {
+			return err
+		}
+		for j := range stream.Consumers {
+			_, err = stream.Consumers[j].ConsumerConfig()
+			if err != nil {
+				return errors.Wrapf(err, "stream %s", stream.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Apply creates or updates every stream through AddStream, then its consumers through EnsureConsumer
+func (m *Manifest) Apply(js JetStream) error {
+	for i := range m.Streams {
+		stream := &m.Streams[i]
+		cfg, err := stream.StreamConfig()
+		if err != nil {
+			return err
+		}
+		_, err = js.AddStream(cfg)
+		if err != nil {
+			return errors.Wrapf(err, "failed to apply stream %s", stream.Name)
+		}
+
+		for j := range stream.Consumers {
+			consumerCfg, err := stream.Consumers[j].ConsumerConfig()
+			if err != nil {
+				return errors.Wrapf(err, "stream %s", stream.Name)
+			}
+			_, err = js.EnsureConsumer(stream.Name, consumerCfg)
+			if err != nil {
+				return errors.Wrapf(err, "failed to apply consumer %s of stream %s", consumerCfg.Durable, stream.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// StreamConfig converts the manifest into nats.StreamConfig
+func (s *StreamManifest) StreamConfig() (*nats.StreamConfig, error) {
+	if s.Name == "" {
+		return nil, errors.New("stream name is required")
+	}
+
+	cfg := &nats.StreamConfig{
+		Name:              s.Name,
+		Description:       s.Description,
+		Subjects:          s.Subjects,
+		Replicas:          s.Replicas,
+		MaxMsgs:           s.MaxMsgs,
+		MaxBytes:          s.MaxBytes,
+		MaxMsgsPerSubject: s.MaxMsgsPerSubject,
+		MaxMsgSize:        s.MaxMsgSize,
+	}
+
+	err := firstError(
+		parseEnum(s.Retention, &cfg.Retention),
+		parseEnum(s.Storage, &cfg.Storage),
+		parseEnum(s.Discard, &cfg.Discard),
+		parseDuration(s.MaxAge, &cfg.MaxAge),
+		parseDuration(s.Duplicates, &cfg.Duplicates),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "stream %s", s.Name)
+	}
+	return cfg, nil
+}
+
+// ConsumerConfig converts the manifest into nats.ConsumerConfig
+func (c *ConsumerManifest) ConsumerConfig() (*nats.ConsumerConfig, error) {
+	if c.Durable == "" {
+		return nil, errors.New("consumer durable is required")
+	}
+
+	cfg := &nats.ConsumerConfig{
+		Durable:        c.Durable,
+		Description:    c.Description,
+		FilterSubject:  c.FilterSubject,
+		FilterSubjects: c.FilterSubjects,
+		AckPolicy:      nats.AckExplicitPolicy,
+		MaxDeliver:     c.MaxDeliver,
+		MaxAckPending:  c.MaxAckPending,
+		DeliverSubject: c.DeliverSubject,
+		DeliverGroup:   c.DeliverGroup,
+	}
+
+	err := firstError(
+		parseEnum(c.DeliverPolicy, &cfg.DeliverPolicy),
+		parseEnum(c.AckPolicy, &cfg.AckPolicy),
+		parseEnum(c.ReplayPolicy, &cfg.ReplayPolicy),
+		parseDuration(c.AckWait, &cfg.AckWait),
+	)
+	for _, backOff := range c.BackOff {
+		var d time.Duration
+		err = firstError(err, parseDuration(backOff, &d))
+		cfg.BackOff = append(cfg.BackOff, d)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "consumer %s", c.Durable)
+	}
+	return cfg, nil
+}
+
+// RegisterNATSJetStream :nodoc:
+func (r *ManifestStreamRegistrar) RegisterNATSJetStream(js JetStream) {
+	r.js = js
+}
+
+// InitStream applies the manifest
+func (r *ManifestStreamRegistrar) InitStream() error {
+	return r.manifest.Apply(r.js)
+}
+
+// NewManifestStreamRegistrar :nodoc:
+func NewManifestStreamRegistrar(m *Manifest) *ManifestStreamRegistrar {
+	return &ManifestStreamRegistrar{manifest: m}
+}
+
+// parseEnum parses the value with the JSON unmarshaler of the nats enum type, e.g. nats.RetentionPolicy, the zero value is kept when it is empty
+func parseEnum(value string, enum json.Unmarshaler) error {
+	if value == "" {
+		return nil
+	}
+	return enum.UnmarshalJSON([]byte(`"` + value + `"`))
+}
+
+func parseDuration(value string, d *time.Duration) (err error) {
+	if value == "" {
+		return nil
+	}
+	*d, err = time.ParseDuration(value)
+	return err
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package ferstream
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testManifestYAML = `
+streams:
+  - name: STREAM_NAME_MANIFEST
+    description: manifest stream
+    subjects: ["STREAM_NAME_MANIFEST.*"]
+    retention: limits
+    storage: file
+    max_age: 72h
+    max_msgs_per_subject: 100
+    consumers:
+      - durable: manifest_consumer
+        filter_subjects: ["STREAM_NAME_MANIFEST.A", "STREAM_NAME_MANIFEST.B"]
+        deliver_policy: new
+        ack_wait: 1m
+        max_deliver: 5
+        backoff: [1s, 5s]
+`
+
+func TestParseManifest(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		m, err := ParseManifest([]byte(testManifestYAML))
+		require.NoError(t, err)
+		require.Len(t, m.Streams, 1)
+
+		cfg, err := m.Streams[0].StreamConfig()
+		require.NoError(t, err)
+		assert.Equal(t, &nats.StreamConfig{
+			Name:              "STREAM_NAME_MANIFEST",
+			Description:       "manifest stream",
+			Subjects:          []string{"STREAM_NAME_MANIFEST.*"},
+			Retention:         nats.LimitsPolicy,
+			Storage:           nats.FileStorage,
+			MaxAge:            72 * time.Hour,
+			MaxMsgsPerSubject: 100,
+		}, cfg)
+
+		consumerCfg, err := m.Streams[0].Consumers[0].ConsumerConfig()
+		require.NoError(t, err)
+		assert.Equal(t, &nats.ConsumerConfig{
+			Durable:        "manifest_consumer",
+			FilterSubjects: []string{"STREAM_NAME_MANIFEST.A", "STREAM_NAME_MANIFEST.B"},
+			DeliverPolicy:  nats.DeliverNewPolicy,
+			AckPolicy:      nats.AckExplicitPolicy,
+			AckWait:        time.Minute,
+			MaxDeliver:     5,
+			BackOff:        []time.Duration{time.Second, 5 * time.Second},
+		}, consumerCfg)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		m, err := ParseManifest([]byte(`{"streams": [{"name": "STREAM", "storage": "memory", "consumers": [{"durable": "C", "ack_policy": "all"}]}]}`))
+		require.NoError(t, err)
+
+		cfg, err := m.Streams[0].StreamConfig()
+		require.NoError(t, err)
+		assert.Equal(t, nats.MemoryStorage, cfg.Storage)
+
+		consumerCfg, err := m.Streams[0].Consumers[0].ConsumerConfig()
+		require.NoError(t, err)
+		assert.Equal(t, nats.AckAllPolicy, consumerCfg.AckPolicy)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		invalidManifests := map[string]string{
+			"unknown field":    `streams: [{name: STREAM, max_agee: 1h}]`,
+			"missing name":     `streams: [{subjects: [A]}]`,
+			"duplicate stream": `streams: [{name: STREAM}, {name: STREAM}]`,
+			"bad retention":    `streams: [{name: STREAM, retention: forever}]`,
+			"bad duration":     `streams: [{name: STREAM, max_age: 3 days}]`,
+			"missing durable":  `streams: [{name: STREAM, consumers: [{ack_policy: all}]}]`,
+			"bad backoff":      `streams: [{name: STREAM, consumers: [{durable: C, backoff: [1s, soon]}]}]`,
+		}
+		for name, manifest := range invalidManifests {
+			_, err := ParseManifest([]byte(manifest))
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestLoadManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "streams.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testManifestYAML), 0o600))
+
+	m, err := LoadManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, "STREAM_NAME_MANIFEST", m.Streams[0].Name)
+
+	_, err = LoadManifest(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestManifestStreamRegistrar(t *testing.T) {
+	m, err := ParseManifest([]byte(testManifestYAML))
+	require.NoError(t, err)
+
+	n, err := NewNATSConnection(defaultURL, []JetStreamRegistrar{NewManifestStreamRegistrar(m)})
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	streamInfo, err := n.StreamInfo("STREAM_NAME_MANIFEST")
+	require.NoError(t, err)
+	assert.Equal(t, 72*time.Hour, streamInfo.Config.MaxAge)
+
+	consumerInfo, err := n.ConsumerInfo("STREAM_NAME_MANIFEST", "manifest_consumer")
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{time.Second, 5 * time.Second}, consumerInfo.Config.BackOff)
+	desired := consumerInfo.Config
+	desired.AckWait = time.Minute
+	assert.Empty(t, mutableConsumerChanges(&consumerInfo.Config, &desired), "AckWait is ignored with BackOff")
+
+	// applying again is a no-op
+	require.NoError(t, m.Apply(n))
+
+	// a changed consumer is updated
+	m.Streams[0].Consumers[0].MaxDeliver = 10
+	require.NoError(t, m.Apply(n))
+	consumerInfo, err = n.ConsumerInfo("STREAM_NAME_MANIFEST", "manifest_consumer")
+	require.NoError(t, err)
+	assert.Equal(t, 10, consumerInfo.Config.MaxDeliver)
+}
@@ -0,0 +1,566 @@
+package ferstream
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/kumparan/go-utils"
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultAckWait is the JetStream server default AckWait, used when the consumer's AckWait can not be resolved
+const defaultAckWait = 30 * time.Second
+
+type (
+	// subscriptionContexts tracks the context shared by every message delivered to a subscription,
+	// the context is cancelled once the subscription starts draining or is closed
+	subscriptionContexts struct {
+		mu   sync.Mutex
+		subs map[*nats.Subscription]context.Context
+	}
+
+	// HandlerOption optional configuration of the message handler
+	HandlerOption func(*handlerOptions)
+
+	handlerOptions struct {
+		retryPolicy          RetryPolicy
+		maxElapsedTime       time.Duration
+		errHandler           ContextMessageHandler
+		serverSideRedelivery bool
+		// ackWaitDeadline the message context expires after the consumer's AckWait
+		ackWaitDeadline bool
+		deadLetter      *deadLetter
+		tracing         *tracing
+		metrics         Metrics
+		logger          *eventLogger
+	}
+
+	messageHandler struct {
+		newPayload func() MessageParser
+		msgHandler ContextMessageHandler
+		opts       handlerOptions
+		subCtxs    *subscriptionContexts
+	}
+
+	// msgAcker acks, naks and terminates a delivered message
+	msgAcker interface {
+		Ack() error
+		Nak() error
+		NakWithDelay(delay time.Duration) error
+		Term() error
+	}
+
+	// subscriptionMsg the msgAcker of a message delivered to a subscription
+	subscriptionMsg struct {
+		msg *nats.Msg
+	}
+
+	// delivery a message being handled
+	delivery struct {
+		msg      *nats.Msg
+		acker    msgAcker
+		meta     *nats.MsgMetadata
+		payload  MessageParser
+		logger   *eventLogger
+		span     trace.Span
+		attempts int
+		received time.Time
+	}
+)
+
+// WithRetryPolicy sets the retry policy of the message handler
+func WithRetryPolicy(policy RetryPolicy) HandlerOption {
+	return func(o *handlerOptions) {
+		o.retryPolicy = policy
+	}
+}
+
+// WithMaxElapsedTime gives up the message once the next attempt would start after d since the first attempt,
+// regardless of the retry policy. With server side redelivery, the elapsed time is counted since the message is published.
+func WithMaxElapsedTime(d time.Duration) HandlerOption {
+	return func(o *handlerOptions) {
+		o.maxElapsedTime = d
+	}
+}
+
+// WithErrorHandler sets the handler called with the payload once the message is given up
+func WithErrorHandler(errHandler ContextMessageHandler) HandlerOption {
+	return func(o *handlerOptions) {
+		o.errHandler = errHandler
+	}
+}
+
+// WithServerSideRedelivery makes every delivery a single attempt instead of retrying in-process.
+// A failed attempt is nak-ed with a backoff delay, so the server redelivers it and the subscription is not blocked by the retry interval.
+// Attempts are counted from the message's delivery count, the message is terminated and handed over to the error handler after the last attempt.
+// The last attempt is also the last delivery by the MaxDeliver of the consumer, when it is lower than the attempts of the retry policy.
+func WithServerSideRedelivery() HandlerOption {
+	return func(o *handlerOptions) {
+		o.serverSideRedelivery = true
+	}
+}
+
+// withoutAckWaitDeadline keeps the message context from expiring after the consumer's AckWait,
+// so NewNATSMessageHandler retries as long as its retry policy allows
+func withoutAckWaitDeadline() HandlerOption {
+	return func(o *handlerOptions) {
+		o.ackWaitDeadline = false
+	}
+}
+
+func newSubscriptionContexts() *subscriptionContexts {
+	return &subscriptionContexts{
+		subs: make(map[*nats.Subscription]context.Context),
+	}
+}
+
+// messageContext returns a context for processing msg, it is cancelled when the subscription or the consume is drained or closed.
+// With ackWaitDeadline, it also expires once the AckWait of the consumer, resolved when subscribing, passed since msg is delivered.
+func (s *subscriptionContexts) messageContext(msg *nats.Msg, ackWaitDeadline bool, delivered time.Time) (context.Context, context.CancelFunc) {
+	var ctx context.Context
+	if consumed, ok := getConsumedMsg(msg); ok {
+		ctx = consumed.consume.ctx
+	} else {
+		ctx = s.get(msg.Sub)
+	}
+
+	if !ackWaitDeadline {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, delivered.Add(getConsumerLimits(msg).ackWait))
+}
+
+func (s *subscriptionContexts) get(sub *nats.Subscription) context.Context {
+	if sub == nil {
+		return context.Background()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if subCtx, ok := s.subs[sub]; ok {
+		return subCtx
+	}
+
+	subCtx, cancel := context.WithCancel(context.Background())
+	s.subs[sub] = subCtx
+
+	statusCh := sub.StatusChanged(nats.SubscriptionDraining, nats.SubscriptionClosed)
+	go func() {
+		<-statusCh
+		cancel()
+
+		s.mu.Lock()
+		delete(s.subs, sub)
+		s.mu.Unlock()
+	}()
+
+	return subCtx
+}
+
+// withContext adapts MessageHandler into ContextMessageHandler
+func (h MessageHandler) withContext() ContextMessageHandler {
+	if h == nil {
+		return nil
+	}
+	return func(_ context.Context, payload MessageParser) error {
+		return h(payload)
+	}
+}
+
+// NewMessageHandler a wrapper to standardize how we handle NATS messages.
+// Payload (arg 0) should always be empty when the method is called. The payload data will later parse data from msg.Data.
+// Without WithRetryPolicy, the message is attempted 3 times with exponential delay starting from 1 second.
+// The payload is shared by every delivery, use NewTypedHandler when the subscription delivers messages concurrently.
+func NewMessageHandler(payload MessageParser, msgHandler ContextMessageHandler, opts ...HandlerOption) nats.MsgHandler {
+	return newMessageHandler(func() MessageParser { return payload }, msgHandler, opts...).handle
+}
+
+func newMessageHandler(newPayload func() MessageParser, msgHandler ContextMessageHandler, opts ...HandlerOption) *messageHandler {
+	h := &messageHandler{
+		newPayload: newPayload,
+		msgHandler: msgHandler,
+		opts: handlerOptions{
+			retryPolicy:     NewExponentialRetryPolicy(3, time.Second, 2, 0),
+			ackWaitDeadline: true,
+			tracing:         noopTracing,
+			metrics:         noopMetrics{},
+		},
+		subCtxs: newSubscriptionContexts(),
+	}
+	for _, opt := range opts {
+		opt(&h.opts)
+	}
+
+	return h
+}
+
+// NewNATSMessageHandler a wrapper to standardize how we handle NATS messages.
+// Payload (arg 0) should always be empty when the method is called. The payload data will later parse data from msg.Data.
+// The retry interval is doubled after every failed attempt. Prefer NewMessageHandler for new code.
+// Unlike NewNATSContextMessageHandler, the retries are not limited by the consumer's AckWait,
+// they only stop once the subscription is drained.
+func NewNATSMessageHandler(payload MessageParser, retryAttempts int, retryInterval time.Duration, msgHandler MessageHandler, errHandler MessageHandler, opts ...HandlerOption) nats.MsgHandler {
+	opts = append([]HandlerOption{withoutAckWaitDeadline()}, opts...)
+	return NewNATSContextMessageHandler(payload, retryAttempts, retryInterval, msgHandler.withContext(), errHandler.withContext(), opts...)
+}
+
+// NewNATSContextMessageHandler same as NewNATSMessageHandler, but the handlers receive a context which expires after the consumer's AckWait.
+// The AckWait is resolved when subscribing through the JetStream, the server default of 30 seconds is used for other subscriptions.
+// A message whose retries do not fit in the AckWait is given up after its last attempt within the AckWait.
+// The context is also cancelled when the subscription is drained (e.g. on SafeClose), which stops the retry loop
+// and naks the message, so the server can redeliver it.
+func NewNATSContextMessageHandler(payload MessageParser, retryAttempts int, retryInterval time.Duration, msgHandler ContextMessageHandler, errHandler ContextMessageHandler, opts ...HandlerOption) nats.MsgHandler {
+	opts = append([]HandlerOption{
+		WithRetryPolicy(NewExponentialRetryPolicy(retryAttempts, retryInterval, 2, 0)),
+		WithErrorHandler(errHandler),
+	}, opts...)
+	return NewMessageHandler(payload, msgHandler, opts...)
+}
+
+func (h *messageHandler) handle(msg *nats.Msg) {
+	d := &delivery{
+		msg:      msg,
+		acker:    getMsgAcker(msg),
+		payload:  h.newPayload(),
+		logger:   getLogger(h.opts.logger),
+		received: deliveredAt(msg),
+	}
+	h.opts.metrics.MessageReceived(msg.Subject)
+	defer startHandling(msg)()
+
+	ctx, cancel := h.subCtxs.messageContext(msg, h.opts.ackWaitDeadline, d.received)
+	defer cancel()
+
+	ctx, d.span = h.opts.tracing.startProcess(ctx, msg)
+	defer d.span.End()
+
+	meta, err := msgMetadata(msg)
+	if err != nil {
+		d.log(LogEventMetadataFailed, "failed to get message metadata", LogFields{"error-detail": err.Error()})
+	}
+	d.meta = meta
+
+	err = d.parse()
+	d.traceMetadata()
+	if err != nil {
+		d.log(LogEventParseFailed, "unmarshal failed", LogFields{"error-detail": err.Error()})
+		h.opts.metrics.MessageParseFailed(msg.Subject)
+		h.terminate(ctx, d, Permanent(err))
+		return
+	}
+	defer d.logPayload(LogEventMessagePayload, "message payload", nil)
+
+	if h.opts.serverSideRedelivery && d.meta != nil {
+		h.handleRedelivery(ctx, d)
+		return
+	}
+
+	h.handleRetry(ctx, d)
+}
+
+// parse parses msg.Data into the payload, and msg.Header when the payload is a HeaderParser.
+// A message which can not be parsed is a poison message
+func (d *delivery) parse() error {
+	if d.msg.Data == nil {
+		return ErrNilMessagePayload
+	}
+
+	err := d.payload.ParseFromBytes(d.msg.Data)
+	if err != nil {
+		return err
+	}
+
+	d.payload.AddSubject(d.msg.Subject)
+	if headerParser, ok := d.payload.(HeaderParser); ok {
+		headerParser.ParseHeader(d.msg.Header)
+	}
+	return nil
+}
+
+// handleRetry retries the message in-process and acks it once it is handled or given up,
+// a permanent failure is terminated instead. The message is given up once the next attempt would start after the AckWait,
+// since the server would redeliver it with a fresh attempt count.
+func (h *messageHandler) handleRetry(ctx context.Context, d *delivery) {
+	retryErr := h.opts.retry(ctx, func() error {
+		return h.attempt(ctx, d)
+	})
+	if retryErr == nil {
+		h.succeed(d)
+		return
+	}
+
+	if errors.Is(ctx.Err(), context.Canceled) {
+		d.expire(ctx)
+		return
+	}
+
+	if IsPermanent(retryErr) {
+		h.terminate(ctx, d, retryErr)
+		return
+	}
+
+	if !h.giveUp(ctx, d, retryErr) {
+		d.nak()
+		return
+	}
+	d.ack()
+}
+
+// handleRedelivery handles a single attempt per delivery, a failed attempt is redelivered by the server after the retry policy's delay
+// until the retry policy gives up on the message's delivery count, then the message is terminated.
+// The message is also given up on its last delivery by the MaxDeliver of the consumer, since the server would not redeliver it.
+func (h *messageHandler) handleRedelivery(ctx context.Context, d *delivery) {
+	d.attempts = int(d.meta.NumDelivered) - 1
+	err := h.attempt(ctx, d)
+	if err == nil {
+		h.succeed(d)
+		return
+	}
+
+	if errors.Is(ctx.Err(), context.Canceled) {
+		d.expire(ctx)
+		return
+	}
+
+	delay, ok := h.opts.nextDelay(int(d.meta.NumDelivered), time.Since(d.meta.Timestamp), err)
+	if ok && !getConsumerLimits(d.msg).delivered(d.meta.NumDelivered) {
+		d.log(LogEventRetry, err, LogFields{"delay": delay.String()})
+
+		d.settle("nak", d.acker.NakWithDelay(delay))
+		return
+	}
+
+	h.terminate(ctx, d, err)
+}
+
+// attempt calls the message handler within the span of the attempt
+func (h *messageHandler) attempt(ctx context.Context, d *delivery) error {
+	d.attempts++
+	if d.attempts > 1 {
+		h.opts.metrics.MessageRetried(d.msg.Subject)
+	}
+	ctx, span := h.opts.tracing.tracer.Start(ctx, "attempt", trace.WithAttributes(attrAttempt.Int(d.attempts)))
+	defer span.End()
+
+	err := h.msgHandler(ctx, d.payload)
+	recordError(span, err)
+	return err
+}
+
+// succeed acks the handled message
+func (h *messageHandler) succeed(d *delivery) {
+	h.opts.metrics.MessageSucceeded(d.msg.Subject, time.Since(d.received))
+	markSucceeded(d.msg)
+	d.ack()
+}
+
+// terminate gives up the message and terminates it, so the server stops redelivering it
+func (h *messageHandler) terminate(ctx context.Context, d *delivery, cause error) {
+	if !h.giveUp(ctx, d, cause) {
+		d.nak()
+		return
+	}
+
+	d.settle("term", d.acker.Term())
+}
+
+// giveUp logs the last handler error, republishes the message to the dead letter subject if configured,
+// and hands the payload over to the error handler. It returns false when the message can not be dead lettered
+// and should be redelivered instead of acked.
+func (h *messageHandler) giveUp(ctx context.Context, d *delivery, cause error) bool {
+	recordError(d.span, cause)
+	d.logPayload(LogEventGiveUp, cause, LogFields{"cause": ErrGiveUpProcessingMessagePayload.Error()})
+
+	if h.opts.deadLetter != nil {
+		err := h.opts.deadLetter.publish(d.msg, d.meta, cause)
+		if err != nil {
+			d.log(LogEventDeadLetterFailed, "failed to publish message to dead letter subject", LogFields{"cause": err.Error()})
+			return false
+		}
+	}
+	h.opts.metrics.MessageGivenUp(d.msg.Subject, time.Since(d.received))
+
+	if h.opts.errHandler == nil {
+		return true
+	}
+
+	// the message exceeded its AckWait, the error handler still gets to handle it
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		ctx = context.WithoutCancel(ctx)
+	}
+
+	// hand over to error handler
+	ctx, span := h.opts.tracing.tracer.Start(ctx, "error handler")
+	defer span.End()
+	err := h.opts.errHandler(ctx, d.payload)
+	recordError(span, err)
+	if err != nil {
+		h.opts.metrics.ErrorHandlerFailed(d.msg.Subject)
+		d.logPayload(LogEventErrorHandlerFailed, err, nil)
+	}
+	return true
+}
+
+func (d *delivery) ack() {
+	d.settle("ack", d.acker.Ack())
+}
+
+func (d *delivery) nak() {
+	d.settle("nak", d.acker.Nak())
+}
+
+// getMsgAcker returns the msgAcker of msg, the jetstream.Msg of a message given by Consume
+func getMsgAcker(msg *nats.Msg) msgAcker {
+	if consumed, ok := getConsumedMsg(msg); ok {
+		return consumed
+	}
+	return subscriptionMsg{msg: msg}
+}
+
+// msgMetadata returns the JetStream metadata of msg, also of a message given by Consume
+func msgMetadata(msg *nats.Msg) (*nats.MsgMetadata, error) {
+	if consumed, ok := getConsumedMsg(msg); ok {
+		return consumed.metadata()
+	}
+	return msg.Metadata()
+}
+
+// Ack :nodoc:
+func (m subscriptionMsg) Ack() error {
+	return m.msg.Ack()
+}
+
+// Nak :nodoc:
+func (m subscriptionMsg) Nak() error {
+	return m.msg.Nak()
+}
+
+// NakWithDelay :nodoc:
+func (m subscriptionMsg) NakWithDelay(delay time.Duration) error {
+	return m.msg.NakWithDelay(delay)
+}
+
+// Term :nodoc:
+func (m subscriptionMsg) Term() error {
+	return m.msg.Term()
+}
+
+// settle records the ack, nak or term on the delivery span and logs its error
+func (d *delivery) settle(event string, err error) {
+	d.span.AddEvent(event)
+	if err != nil {
+		d.span.RecordError(err)
+		d.log(LogEventAckFailed, err, LogFields{"action": event})
+	}
+}
+
+// log logs the event with the subject and the JetStream metadata of the message
+func (d *delivery) log(event LogEvent, msg any, fields LogFields) {
+	if !d.logger.enabled(event) {
+		return
+	}
+
+	entryFields := LogFields{"subject": d.msg.Subject}
+	if d.meta != nil {
+		entryFields["stream"] = d.meta.Stream
+		entryFields["stream-sequence"] = d.meta.Sequence.Stream
+		entryFields["num-delivered"] = d.meta.NumDelivered
+	}
+	maps.Copy(entryFields, fields)
+	d.logger.log(event, msg, entryFields)
+}
+
+// logPayload same as log, with the payload redacted and truncated by the log config
+func (d *delivery) logPayload(event LogEvent, msg any, fields LogFields) {
+	if !d.logger.enabled(event) {
+		return
+	}
+
+	fields = maps.Clone(fields)
+	if fields == nil {
+		fields = LogFields{}
+	}
+	fields["payload"] = d.logger.payload(d.payload)
+	d.log(event, msg, fields)
+}
+
+// expire naks the message when the subscription is drained, so it is redelivered without waiting for the AckWait
+func (d *delivery) expire(ctx context.Context) {
+	d.log(LogEventExpired, "stop processing message", LogFields{"cause": ctx.Err().Error()})
+	recordError(d.span, ctx.Err())
+	d.nak()
+}
+
+// nextDelay returns the delay before the next attempt after err, ok is false when the message should be given up
+func (o *handlerOptions) nextDelay(failedAttempts int, elapsed time.Duration, err error) (delay time.Duration, ok bool) {
+	if IsPermanent(err) {
+		return 0, false
+	}
+
+	delay, ok = o.retryPolicy.NextDelay(failedAttempts)
+	if !ok {
+		return 0, false
+	}
+
+	if d, isRetryAfter := retryAfter(err); isRetryAfter {
+		delay = d
+	}
+
+	if o.maxElapsedTime > 0 && elapsed+delay > o.maxElapsedTime {
+		return 0, false
+	}
+	return delay, true
+}
+
+// retry attempts fn until it succeeds or the retry policy gives up, it stops as soon as ctx is done
+// and returns the last error of fn, or the error of ctx when fn is not attempted.
+// It also gives up when the next attempt would start after the deadline of ctx.
+// Same as utils.Retry, a utils.RetryStopper stops the retry and its error is returned.
+func (o *handlerOptions) retry(ctx context.Context, fn func() error) error {
+	start := time.Now()
+	var lastErr error
+	for failedAttempts := 1; ; failedAttempts++ {
+		if ctx.Err() != nil {
+			return cmp.Or(lastErr, ctx.Err())
+		}
+
+		lastErr = fn()
+		if lastErr == nil {
+			return nil
+		}
+
+		var stopper utils.RetryStopper
+		if errors.As(lastErr, &stopper) {
+			return unwrapRetryStopper(stopper)
+		}
+
+		delay, ok := o.nextDelay(failedAttempts, time.Since(start), lastErr)
+		if !ok {
+			return lastErr
+		}
+		if deadline, hasDeadline := ctx.Deadline(); hasDeadline && time.Until(deadline) < delay {
+			return lastErr
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return lastErr
+		case <-timer.C:
+		}
+	}
+}
+
+// unwrapRetryStopper returns the error wrapped by stopper, utils.Retry returns it from the first attempt
+func unwrapRetryStopper(stopper utils.RetryStopper) error {
+	return utils.Retry(1, 0, func() error {
+		return stopper
+	})
+}
@@ -0,0 +1,537 @@
+package ferstream
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kumparan/go-utils"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestNatsEventMessage(t *testing.T) []byte {
+	msgBytes, err := NewNatsEventMessage().WithEvent(&NatsEvent{
+		ID:     int64(1232),
+		UserID: int64(21),
+	}).Build()
+	require.NoError(t, err)
+	return msgBytes
+}
+
+func TestNewNATSMessageHandler(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	_, err = n.AddStream(&nats.StreamConfig{
+		Name:     "STREAM_NAME_MESSAGE_HANDLER",
+		Subjects: []string{"STREAM_NAME_MESSAGE_HANDLER.*"},
+		Storage:  nats.FileStorage,
+	})
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		subject := "STREAM_NAME_MESSAGE_HANDLER.SUCCESS"
+		receiverCh := make(chan MessageParser, 1)
+		msgHandler := func(payload MessageParser) error {
+			receiverCh <- payload
+			return nil
+		}
+
+		sub, err := n.Subscribe(subject, NewNATSMessageHandler(NewNatsEventMessage(), 3, time.Millisecond, msgHandler, nil),
+			nats.Durable("message_handler_success"), nats.ManualAck(), nats.DeliverNew())
+		require.NoError(t, err)
+		defer func() { _ = sub.Unsubscribe() }()
+
+		_, err = n.Publish(subject, newTestNatsEventMessage(t))
+		require.NoError(t, err)
+
+		payload := <-receiverCh
+		msg, ok := payload.(*NatsEventMessage)
+		require.True(t, ok)
+		assert.Equal(t, int64(1232), msg.NatsEvent.GetID())
+		assert.Equal(t, subject, msg.NatsEvent.GetSubject())
+	})
+
+	t.Run("headers", func(t *testing.T) {
+		subject := "STREAM_NAME_MESSAGE_HANDLER.HEADERS"
+		receiverCh := make(chan *NatsEventMessage, 1)
+		msgHandler := func(payload MessageParser) error {
+			msg := payload.(*NatsEventMessage)
+			receiverCh <- &NatsEventMessage{NatsEvent: msg.NatsEvent, Header: msg.Header}
+			return nil
+		}
+
+		sub, err := n.Subscribe(subject, NewNATSMessageHandler(NewNatsEventMessage(), 3, time.Millisecond, msgHandler, nil),
+			nats.Durable("message_handler_headers"), nats.ManualAck(), nats.DeliverNew())
+		require.NoError(t, err)
+		defer func() { _ = sub.Unsubscribe() }()
+
+		natsMsg, err := NewNatsEventMessage().
+			WithEvent(&NatsEvent{ID: 1232, UserID: 21, TenantID: 7}).
+			WithEventType("story.published").
+			BuildMsg(subject)
+		require.NoError(t, err)
+		_, err = n.PublishMsg(natsMsg)
+		require.NoError(t, err)
+
+		msg := <-receiverCh
+		assert.Equal(t, "story.published", msg.GetEventType())
+		assert.Equal(t, "7", msg.GetHeader(HeaderTenantID))
+
+		// the shared payload does not keep the headers of the previous message
+		_, err = n.Publish(subject, newTestNatsEventMessage(t))
+		require.NoError(t, err)
+
+		msg = <-receiverCh
+		assert.Empty(t, msg.GetEventType())
+	})
+
+	t.Run("give up and handle error", func(t *testing.T) {
+		subject := "STREAM_NAME_MESSAGE_HANDLER.GIVE_UP"
+		var attempts int32
+		msgHandler := func(_ MessageParser) error {
+			atomic.AddInt32(&attempts, 1)
+			return assert.AnError
+		}
+		errHandlerCh := make(chan MessageParser, 1)
+		errHandler := func(payload MessageParser) error {
+			errHandlerCh <- payload
+			return nil
+		}
+
+		sub, err := n.Subscribe(subject, NewNATSMessageHandler(NewNatsEventMessage(), 3, time.Millisecond, msgHandler, errHandler),
+			nats.Durable("message_handler_give_up"), nats.ManualAck(), nats.DeliverNew())
+		require.NoError(t, err)
+		defer func() { _ = sub.Unsubscribe() }()
+
+		_, err = n.Publish(subject, newTestNatsEventMessage(t))
+		require.NoError(t, err)
+
+		select {
+		case <-errHandlerCh:
+		case <-time.After(5 * time.Second):
+			t.Fatal("error handler is not called")
+		}
+		assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("permanent error is not retried", func(t *testing.T) {
+		subject := "STREAM_NAME_MESSAGE_HANDLER.PERMANENT"
+		var attempts int32
+		msgHandler := func(_ MessageParser) error {
+			atomic.AddInt32(&attempts, 1)
+			return Permanent(assert.AnError)
+		}
+		errHandlerCh := make(chan MessageParser, 1)
+		errHandler := func(payload MessageParser) error {
+			errHandlerCh <- payload
+			return nil
+		}
+
+		sub, err := n.Subscribe(subject, NewNATSMessageHandler(NewNatsEventMessage(), 3, time.Hour, msgHandler, errHandler),
+			nats.Durable("message_handler_permanent"), nats.ManualAck(), nats.DeliverNew())
+		require.NoError(t, err)
+		defer func() { _ = sub.Unsubscribe() }()
+
+		_, err = n.Publish(subject, newTestNatsEventMessage(t))
+		require.NoError(t, err)
+
+		select {
+		case <-errHandlerCh:
+		case <-time.After(5 * time.Second):
+			t.Fatal("error handler is not called")
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("unparseable payload is handed over to error handler", func(t *testing.T) {
+		subject := "STREAM_NAME_MESSAGE_HANDLER.POISON"
+		msgHandler := func(_ MessageParser) error {
+			t.Error("message handler should not be called")
+			return nil
+		}
+		errHandlerCh := make(chan MessageParser, 1)
+		errHandler := func(payload MessageParser) error {
+			errHandlerCh <- payload
+			return nil
+		}
+
+		sub, err := n.Subscribe(subject, NewNATSMessageHandler(NewNatsEventMessage(), 3, time.Millisecond, msgHandler, errHandler),
+			nats.Durable("message_handler_poison"), nats.ManualAck(), nats.DeliverNew())
+		require.NoError(t, err)
+		defer func() { _ = sub.Unsubscribe() }()
+
+		_, err = n.Publish(subject, []byte("not a json"))
+		require.NoError(t, err)
+
+		select {
+		case <-errHandlerCh:
+		case <-time.After(5 * time.Second):
+			t.Fatal("error handler is not called")
+		}
+	})
+}
+
+func TestNewMessageHandler(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	_, err = n.AddStream(&nats.StreamConfig{
+		Name:     "STREAM_NAME_NEW_MESSAGE_HANDLER",
+		Subjects: []string{"STREAM_NAME_NEW_MESSAGE_HANDLER.*"},
+		Storage:  nats.FileStorage,
+	})
+	require.NoError(t, err)
+
+	subject := "STREAM_NAME_NEW_MESSAGE_HANDLER.TEST"
+	var attempts int32
+	msgHandler := func(_ context.Context, _ MessageParser) error {
+		atomic.AddInt32(&attempts, 1)
+		return assert.AnError
+	}
+	errHandlerCh := make(chan MessageParser, 1)
+	errHandler := func(_ context.Context, payload MessageParser) error {
+		errHandlerCh <- payload
+		return nil
+	}
+
+	handler := NewMessageHandler(NewNatsEventMessage(), msgHandler,
+		WithRetryPolicy(NewScheduleRetryPolicy(time.Millisecond, 2*time.Millisecond, 3*time.Millisecond)),
+		WithErrorHandler(errHandler))
+	sub, err := n.Subscribe(subject, handler, nats.Durable("new_message_handler"), nats.ManualAck(), nats.DeliverNew())
+	require.NoError(t, err)
+	defer func() { _ = sub.Unsubscribe() }()
+
+	_, err = n.Publish(subject, newTestNatsEventMessage(t))
+	require.NoError(t, err)
+
+	select {
+	case <-errHandlerCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("error handler is not called")
+	}
+	assert.Equal(t, int32(4), atomic.LoadInt32(&attempts))
+}
+
+func TestNewNATSMessageHandler_WithServerSideRedelivery(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	_, err = n.AddStream(&nats.StreamConfig{
+		Name:     "STREAM_NAME_REDELIVERY",
+		Subjects: []string{"STREAM_NAME_REDELIVERY.*"},
+		Storage:  nats.FileStorage,
+	})
+	require.NoError(t, err)
+
+	t.Run("terminate after the last attempt", func(t *testing.T) {
+		subject := "STREAM_NAME_REDELIVERY.TEST"
+		var attempts int32
+		msgHandler := func(_ MessageParser) error {
+			atomic.AddInt32(&attempts, 1)
+			return assert.AnError
+		}
+		errHandlerCh := make(chan MessageParser, 1)
+		errHandler := func(payload MessageParser) error {
+			errHandlerCh <- payload
+			return nil
+		}
+
+		handler := NewNATSMessageHandler(NewNatsEventMessage(), 3, 10*time.Millisecond, msgHandler, errHandler, WithServerSideRedelivery())
+		sub, err := n.Subscribe(subject, handler, nats.Durable("redelivery"), nats.ManualAck(), nats.DeliverNew())
+		require.NoError(t, err)
+		defer func() { _ = sub.Unsubscribe() }()
+
+		_, err = n.Publish(subject, newTestNatsEventMessage(t))
+		require.NoError(t, err)
+
+		select {
+		case <-errHandlerCh:
+		case <-time.After(5 * time.Second):
+			t.Fatal("error handler is not called")
+		}
+		assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+
+		// terminated message must not be redelivered
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("give up on the MaxDeliver of the consumer", func(t *testing.T) {
+		subject := "STREAM_NAME_REDELIVERY.MAX_DELIVER"
+		var attempts int32
+		msgHandler := func(_ MessageParser) error {
+			atomic.AddInt32(&attempts, 1)
+			return assert.AnError
+		}
+		errHandlerCh := make(chan MessageParser, 1)
+		errHandler := func(payload MessageParser) error {
+			errHandlerCh <- payload
+			return nil
+		}
+
+		handler := NewNATSMessageHandler(NewNatsEventMessage(), 3, 10*time.Millisecond, msgHandler, errHandler, WithServerSideRedelivery())
+		sub, err := n.Subscribe(subject, handler, nats.Durable("redelivery_max_deliver"), nats.ManualAck(), nats.DeliverNew(), nats.MaxDeliver(2))
+		require.NoError(t, err)
+		defer func() { _ = sub.Unsubscribe() }()
+
+		_, err = n.Publish(subject, newTestNatsEventMessage(t))
+		require.NoError(t, err)
+
+		select {
+		case <-errHandlerCh:
+		case <-time.After(5 * time.Second):
+			t.Fatal("error handler is not called")
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+	})
+}
+
+func TestNewNATSContextMessageHandler(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	_, err = n.AddStream(&nats.StreamConfig{
+		Name:     "STREAM_NAME_CONTEXT_MESSAGE_HANDLER",
+		Subjects: []string{"STREAM_NAME_CONTEXT_MESSAGE_HANDLER.*"},
+		Storage:  nats.FileStorage,
+	})
+	require.NoError(t, err)
+
+	t.Run("deadline from consumer AckWait", func(t *testing.T) {
+		subject := "STREAM_NAME_CONTEXT_MESSAGE_HANDLER.DEADLINE"
+		ackWait := 5 * time.Second
+		deadlineCh := make(chan time.Time, 1)
+		msgHandler := func(ctx context.Context, _ MessageParser) error {
+			deadline, ok := ctx.Deadline()
+			assert.True(t, ok)
+			deadlineCh <- deadline
+			return nil
+		}
+
+		sub, err := n.Subscribe(subject, NewNATSContextMessageHandler(NewNatsEventMessage(), 3, time.Millisecond, msgHandler, nil),
+			nats.Durable("context_message_handler_deadline"), nats.ManualAck(), nats.DeliverNew(), nats.AckWait(ackWait))
+		require.NoError(t, err)
+		defer func() { _ = sub.Unsubscribe() }()
+
+		_, err = n.Publish(subject, newTestNatsEventMessage(t))
+		require.NoError(t, err)
+
+		deadline := <-deadlineCh
+		assert.WithinDuration(t, time.Now().Add(ackWait), deadline, time.Second)
+	})
+
+	t.Run("give up when retries exceed AckWait", func(t *testing.T) {
+		subject := "STREAM_NAME_CONTEXT_MESSAGE_HANDLER.ACK_WAIT"
+		var attempts, givenUp int32
+		msgHandler := func(_ context.Context, _ MessageParser) error {
+			atomic.AddInt32(&attempts, 1)
+			return assert.AnError
+		}
+		errHandler := func(_ context.Context, _ MessageParser) error {
+			atomic.AddInt32(&givenUp, 1)
+			return nil
+		}
+
+		// attempts at 0 and 600ms, the third one at 1.8s would start after the AckWait
+		sub, err := n.Subscribe(subject, NewNATSContextMessageHandler(NewNatsEventMessage(), 3, 600*time.Millisecond, msgHandler, errHandler),
+			nats.Durable("context_message_handler_ack_wait"), nats.ManualAck(), nats.DeliverNew(), nats.AckWait(time.Second))
+		require.NoError(t, err)
+		defer func() { _ = sub.Unsubscribe() }()
+
+		_, err = n.Publish(subject, newTestNatsEventMessage(t))
+		require.NoError(t, err)
+
+		assert.Eventually(t, func() bool {
+			return atomic.LoadInt32(&givenUp) == 1
+		}, 2*time.Second, 10*time.Millisecond)
+
+		// the given up message must not be redelivered
+		time.Sleep(1500 * time.Millisecond)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&givenUp))
+	})
+
+	t.Run("legacy handler retries past AckWait", func(t *testing.T) {
+		subject := "STREAM_NAME_CONTEXT_MESSAGE_HANDLER.LEGACY"
+		var attempts int32
+		givenUpCh := make(chan struct{}, 1)
+		msgHandler := func(_ MessageParser) error {
+			atomic.AddInt32(&attempts, 1)
+			return assert.AnError
+		}
+		errHandler := func(_ MessageParser) error {
+			givenUpCh <- struct{}{}
+			return nil
+		}
+
+		// attempts at 0, 600ms and 1.8s, the last one after the AckWait
+		sub, err := n.Subscribe(subject, NewNATSMessageHandler(NewNatsEventMessage(), 3, 600*time.Millisecond, msgHandler, errHandler),
+			nats.Durable("context_message_handler_legacy"), nats.ManualAck(), nats.DeliverNew(), nats.AckWait(time.Second), nats.MaxDeliver(1))
+		require.NoError(t, err)
+		defer func() { _ = sub.Unsubscribe() }()
+
+		_, err = n.Publish(subject, newTestNatsEventMessage(t))
+		require.NoError(t, err)
+
+		select {
+		case <-givenUpCh:
+		case <-time.After(5 * time.Second):
+			t.Fatal("error handler is not called")
+		}
+		assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("stop retrying when subscription is drained", func(t *testing.T) {
+		subject := "STREAM_NAME_CONTEXT_MESSAGE_HANDLER.DRAIN"
+		startedCh := make(chan struct{}, 1)
+		doneCh := make(chan error, 1)
+		msgHandler := func(ctx context.Context, _ MessageParser) error {
+			select {
+			case startedCh <- struct{}{}:
+			default:
+			}
+			return assert.AnError
+		}
+		errHandler := func(ctx context.Context, _ MessageParser) error {
+			doneCh <- errors.New("error handler should not be called")
+			return nil
+		}
+
+		sub, err := n.Subscribe(subject, NewNATSContextMessageHandler(NewNatsEventMessage(), 3, 10*time.Second, msgHandler, errHandler),
+			nats.Durable("context_message_handler_drain"), nats.ManualAck(), nats.DeliverNew())
+		require.NoError(t, err)
+
+		_, err = n.Publish(subject, newTestNatsEventMessage(t))
+		require.NoError(t, err)
+
+		<-startedCh
+		require.NoError(t, sub.Drain())
+
+		select {
+		case err := <-doneCh:
+			t.Fatal(err)
+		case <-time.After(500 * time.Millisecond):
+		}
+	})
+}
+
+func TestHandlerOptions_retry(t *testing.T) {
+	opts := &handlerOptions{retryPolicy: NewExponentialRetryPolicy(3, time.Millisecond, 2, 0)}
+
+	t.Run("success after retry", func(t *testing.T) {
+		var attempts int
+		err := opts.retry(context.Background(), func() error {
+			attempts++
+			if attempts < 2 {
+				return assert.AnError
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("exhaust attempts", func(t *testing.T) {
+		var attempts int
+		err := opts.retry(context.Background(), func() error {
+			attempts++
+			return assert.AnError
+		})
+		assert.Equal(t, assert.AnError, err)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("stop on permanent error", func(t *testing.T) {
+		var attempts int
+		err := opts.retry(context.Background(), func() error {
+			attempts++
+			return Permanent(assert.AnError)
+		})
+		assert.True(t, IsPermanent(err))
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("retry after given delay", func(t *testing.T) {
+		opts := &handlerOptions{retryPolicy: NewExponentialRetryPolicy(2, time.Hour, 2, 0)}
+		var attempts int
+		start := time.Now()
+		err := opts.retry(context.Background(), func() error {
+			attempts++
+			return RetryAfter(assert.AnError, 10*time.Millisecond)
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 2, attempts)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("stop after max elapsed time", func(t *testing.T) {
+		opts := &handlerOptions{
+			retryPolicy:    NewExponentialRetryPolicy(10, 100*time.Millisecond, 1, 0),
+			maxElapsedTime: 250 * time.Millisecond,
+		}
+		var attempts int
+		err := opts.retry(context.Background(), func() error {
+			attempts++
+			return assert.AnError
+		})
+		assert.Equal(t, assert.AnError, err)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("stop before the deadline of the context", func(t *testing.T) {
+		opts := &handlerOptions{retryPolicy: NewExponentialRetryPolicy(3, 100*time.Millisecond, 1, 0)}
+		ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+		defer cancel()
+		var attempts int
+		err := opts.retry(ctx, func() error {
+			attempts++
+			return assert.AnError
+		})
+		assert.Equal(t, assert.AnError, err)
+		assert.Equal(t, 2, attempts)
+		assert.NoError(t, ctx.Err())
+	})
+
+	t.Run("stop on cancelled context", func(t *testing.T) {
+		opts := &handlerOptions{retryPolicy: NewExponentialRetryPolicy(3, time.Hour, 2, 0)}
+		ctx, cancel := context.WithCancel(context.Background())
+		var attempts int
+		err := opts.retry(ctx, func() error {
+			attempts++
+			cancel()
+			return assert.AnError
+		})
+		assert.Equal(t, assert.AnError, err)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("return the last error once the context expires", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := opts.retry(ctx, func() error {
+			<-ctx.Done()
+			return assert.AnError
+		})
+		assert.Equal(t, assert.AnError, err)
+	})
+
+	t.Run("return the error of a retry stopper", func(t *testing.T) {
+		var attempts int
+		err := opts.retry(context.Background(), func() error {
+			attempts++
+			return utils.NewRetryStopper(assert.AnError)
+		})
+		assert.Equal(t, assert.AnError, err)
+		assert.Equal(t, 1, attempts)
+	})
+}
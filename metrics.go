@@ -0,0 +1,79 @@
+package ferstream
+
+import (
+	"time"
+)
+
+// ConnectionState state of the NATS connection reported to Metrics
+type ConnectionState string
+
+// connection states
+const (
+	ConnectionStateConnected    ConnectionState = "connected"
+	ConnectionStateDisconnected ConnectionState = "disconnected"
+	ConnectionStateReconnected  ConnectionState = "reconnected"
+	ConnectionStateClosed       ConnectionState = "closed"
+)
+
+type (
+	// Metrics receives the publish, message handler and connection events,
+	// the metrics package offers a Prometheus implementation. The subject is the message subject.
+	Metrics interface {
+		// ObservePublish is called after every publish, err is the publish error
+		ObservePublish(subject string, duration time.Duration, err error)
+		// MessageReceived is called when the message handler receives a message
+		MessageReceived(subject string)
+		// MessageParseFailed is called when the message can not be parsed into the payload
+		MessageParseFailed(subject string)
+		// MessageRetried is called when a failed attempt is retried, in-process or by the server
+		MessageRetried(subject string)
+		// MessageSucceeded is called when the message is handled, duration is counted since the message is received
+		MessageSucceeded(subject string, duration time.Duration)
+		// MessageGivenUp is called when the message is given up, duration is counted since the message is received
+		MessageGivenUp(subject string, duration time.Duration)
+		// ErrorHandlerFailed is called when the error handler returns an error
+		ErrorHandlerFailed(subject string)
+		// ConnectionStateChanged is called by the connection handlers of NewNATSConnectionWithOptions
+		ConnectionStateChanged(state ConnectionState)
+	}
+
+	noopMetrics struct{}
+)
+
+// WithMetrics reports the message handler events to m
+func WithMetrics(m Metrics) HandlerOption {
+	return func(o *handlerOptions) {
+		o.metrics = m
+	}
+}
+
+// WithConnectionMetrics reports the publish and connection events to m
+func WithConnectionMetrics(m Metrics) ConnectionOption {
+	return func(o *connectionOptions) {
+		o.metrics = m
+	}
+}
+
+// ObservePublish :nodoc:
+func (noopMetrics) ObservePublish(string, time.Duration, error) {}
+
+// MessageReceived :nodoc:
+func (noopMetrics) MessageReceived(string) {}
+
+// MessageParseFailed :nodoc:
+func (noopMetrics) MessageParseFailed(string) {}
+
+// MessageRetried :nodoc:
+func (noopMetrics) MessageRetried(string) {}
+
+// MessageSucceeded :nodoc:
+func (noopMetrics) MessageSucceeded(string, time.Duration) {}
+
+// MessageGivenUp :nodoc:
+func (noopMetrics) MessageGivenUp(string, time.Duration) {}
+
+// ErrorHandlerFailed :nodoc:
+func (noopMetrics) ErrorHandlerFailed(string) {}
+
+// ConnectionStateChanged :nodoc:
+func (noopMetrics) ConnectionStateChanged(ConnectionState) {}
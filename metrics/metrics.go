@@ -0,0 +1,215 @@
+// Package metrics implements ferstream.Metrics with Prometheus
+package metrics
+
+import (
+	"time"
+
+	"github.com/kumparan/ferstream"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultNamespace default namespace of the metric names
+const DefaultNamespace = "ferstream"
+
+// label values of the result and outcome labels
+const (
+	resultSuccess = "success"
+	resultError   = "error"
+	outcomeGiveUp = "give_up"
+)
+
+// connectionStates states of the connection state gauge, a reconnect sets the connected state
+var connectionStates = []ferstream.ConnectionState{
+	ferstream.ConnectionStateConnected,
+	ferstream.ConnectionStateDisconnected,
+	ferstream.ConnectionStateClosed,
+}
+
+type (
+	// Collector a ferstream.Metrics and prometheus.Collector, register it to a prometheus.Registerer
+	// and pass it to ferstream.WithConnectionMetrics and ferstream.WithMetrics
+	Collector struct {
+		publishTotal       *prometheus.CounterVec
+		publishDuration    *prometheus.HistogramVec
+		received           *prometheus.CounterVec
+		parseFailed        *prometheus.CounterVec
+		retried            *prometheus.CounterVec
+		processed          *prometheus.CounterVec
+		processingDuration *prometheus.HistogramVec
+		errHandlerFailed   *prometheus.CounterVec
+		connectionState    *prometheus.GaugeVec
+		reconnects         prometheus.Counter
+	}
+
+	// Option optional configuration of New
+	Option func(*options)
+
+	options struct {
+		namespace   string
+		constLabels prometheus.Labels
+		buckets     []float64
+	}
+)
+
+// WithNamespace sets the namespace of the metric names, default to DefaultNamespace
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithConstLabels adds labels with a fixed value to every metric, e.g. the service name
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(o *options) {
+		o.constLabels = labels
+	}
+}
+
+// WithBuckets sets the buckets of the latency histograms in seconds, default to prometheus.DefBuckets
+func WithBuckets(buckets []float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// New :nodoc:
+func New(opts ...Option) *Collector {
+	o := &options{
+		namespace: DefaultNamespace,
+		buckets:   prometheus.DefBuckets,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	counterVec := func(name, help string, labels ...string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: o.constLabels,
+		}, labels)
+	}
+	histogramVec := func(name, help string, labels ...string) *prometheus.HistogramVec {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   o.namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: o.constLabels,
+			Buckets:     o.buckets,
+		}, labels)
+	}
+
+	return &Collector{
+		publishTotal:       counterVec("publish_total", "Number of published messages by result.", "subject", "result"),
+		publishDuration:    histogramVec("publish_duration_seconds", "Latency of the publish until the ack of the server.", "subject"),
+		received:           counterVec("messages_received_total", "Number of messages received by the message handler.", "subject"),
+		parseFailed:        counterVec("messages_parse_failed_total", "Number of messages which can not be parsed into the payload.", "subject"),
+		retried:            counterVec("messages_retried_total", "Number of retried attempts, in-process or by the server.", "subject"),
+		processed:          counterVec("messages_processed_total", "Number of handled or given up messages by outcome.", "subject", "outcome"),
+		processingDuration: histogramVec("message_processing_duration_seconds", "Latency since the message is received until it is handled or given up.", "subject", "outcome"),
+		errHandlerFailed:   counterVec("error_handler_failed_total", "Number of error handler failures.", "subject"),
+		connectionState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			Name:        "connection_state",
+			Help:        "1 for the current state of the NATS connection, 0 for the others.",
+			ConstLabels: o.constLabels,
+		}, []string{"state"}),
+		reconnects: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			Name:        "connection_reconnects_total",
+			Help:        "Number of reconnects of the NATS connection.",
+			ConstLabels: o.constLabels,
+		}),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range c.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range c.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+// ObservePublish implements ferstream.Metrics
+func (c *Collector) ObservePublish(subject string, duration time.Duration, err error) {
+	if err != nil {
+		c.publishTotal.WithLabelValues(subject, resultError).Inc()
+		return
+	}
+	c.publishTotal.WithLabelValues(subject, resultSuccess).Inc()
+	c.publishDuration.WithLabelValues(subject).Observe(duration.Seconds())
+}
+
+// MessageReceived implements ferstream.Metrics
+func (c *Collector) MessageReceived(subject string) {
+	c.received.WithLabelValues(subject).Inc()
+}
+
+// MessageParseFailed implements ferstream.Metrics
+func (c *Collector) MessageParseFailed(subject string) {
+	c.parseFailed.WithLabelValues(subject).Inc()
+}
+
+// MessageRetried implements ferstream.Metrics
+func (c *Collector) MessageRetried(subject string) {
+	c.retried.WithLabelValues(subject).Inc()
+}
+
+// MessageSucceeded implements ferstream.Metrics
+func (c *Collector) MessageSucceeded(subject string, duration time.Duration) {
+	c.observeProcessed(subject, resultSuccess, duration)
+}
+
+// MessageGivenUp implements ferstream.Metrics
+func (c *Collector) MessageGivenUp(subject string, duration time.Duration) {
+	c.observeProcessed(subject, outcomeGiveUp, duration)
+}
+
+// ErrorHandlerFailed implements ferstream.Metrics
+func (c *Collector) ErrorHandlerFailed(subject string) {
+	c.errHandlerFailed.WithLabelValues(subject).Inc()
+}
+
+// ConnectionStateChanged implements ferstream.Metrics
+func (c *Collector) ConnectionStateChanged(state ferstream.ConnectionState) {
+	if state == ferstream.ConnectionStateReconnected {
+		c.reconnects.Inc()
+		state = ferstream.ConnectionStateConnected
+	}
+
+	for _, s := range connectionStates {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		c.connectionState.WithLabelValues(string(s)).Set(value)
+	}
+}
+
+func (c *Collector) observeProcessed(subject, outcome string, duration time.Duration) {
+	c.processed.WithLabelValues(subject, outcome).Inc()
+	c.processingDuration.WithLabelValues(subject, outcome).Observe(duration.Seconds())
+}
+
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.publishTotal,
+		c.publishDuration,
+		c.received,
+		c.parseFailed,
+		c.retried,
+		c.processed,
+		c.processingDuration,
+		c.errHandlerFailed,
+		c.connectionState,
+		c.reconnects,
+	}
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kumparan/ferstream"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	c := New(WithConstLabels(prometheus.Labels{"service": "test"}))
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, reg.Register(c))
+
+	c.ObservePublish("SUBJECT", 10*time.Millisecond, nil)
+	c.ObservePublish("SUBJECT", 0, assert.AnError)
+	c.MessageReceived("SUBJECT")
+	c.MessageReceived("SUBJECT")
+	c.MessageParseFailed("SUBJECT")
+	c.MessageRetried("SUBJECT")
+	c.MessageSucceeded("SUBJECT", time.Second)
+	c.MessageGivenUp("SUBJECT", time.Second)
+	c.ErrorHandlerFailed("SUBJECT")
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.publishTotal.WithLabelValues("SUBJECT", "success")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.publishTotal.WithLabelValues("SUBJECT", "error")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(c.received.WithLabelValues("SUBJECT")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.parseFailed.WithLabelValues("SUBJECT")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.retried.WithLabelValues("SUBJECT")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.processed.WithLabelValues("SUBJECT", "success")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.processed.WithLabelValues("SUBJECT", "give_up")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.errHandlerFailed.WithLabelValues("SUBJECT")))
+	assert.Equal(t, 2, testutil.CollectAndCount(c, "ferstream_message_processing_duration_seconds"))
+
+	err := testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP ferstream_publish_duration_seconds Latency of the publish until the ack of the server.
+# TYPE ferstream_publish_duration_seconds histogram
+ferstream_publish_duration_seconds_bucket{service="test",subject="SUBJECT",le="0.005"} 0
+ferstream_publish_duration_seconds_bucket{service="test",subject="SUBJECT",le="0.01"} 1
+ferstream_publish_duration_seconds_bucket{service="test",subject="SUBJECT",le="0.025"} 1
+ferstream_publish_duration_seconds_bucket{service="test",subject="SUBJECT",le="0.05"} 1
+ferstream_publish_duration_seconds_bucket{service="test",subject="SUBJECT",le="0.1"} 1
+ferstream_publish_duration_seconds_bucket{service="test",subject="SUBJECT",le="0.25"} 1
+ferstream_publish_duration_seconds_bucket{service="test",subject="SUBJECT",le="0.5"} 1
+ferstream_publish_duration_seconds_bucket{service="test",subject="SUBJECT",le="1"} 1
+ferstream_publish_duration_seconds_bucket{service="test",subject="SUBJECT",le="2.5"} 1
+ferstream_publish_duration_seconds_bucket{service="test",subject="SUBJECT",le="5"} 1
+ferstream_publish_duration_seconds_bucket{service="test",subject="SUBJECT",le="10"} 1
+ferstream_publish_duration_seconds_bucket{service="test",subject="SUBJECT",le="+Inf"} 1
+ferstream_publish_duration_seconds_sum{service="test",subject="SUBJECT"} 0.01
+ferstream_publish_duration_seconds_count{service="test",subject="SUBJECT"} 1
+`), "ferstream_publish_duration_seconds")
+	assert.NoError(t, err)
+}
+
+func TestCollector_ConnectionStateChanged(t *testing.T) {
+	c := New(WithNamespace("test"))
+
+	state := func() map[string]float64 {
+		states := map[string]float64{}
+		for _, s := range connectionStates {
+			states[string(s)] = testutil.ToFloat64(c.connectionState.WithLabelValues(string(s)))
+		}
+		return states
+	}
+
+	c.ConnectionStateChanged(ferstream.ConnectionStateConnected)
+	assert.Equal(t, map[string]float64{"connected": 1, "disconnected": 0, "closed": 0}, state())
+
+	c.ConnectionStateChanged(ferstream.ConnectionStateDisconnected)
+	assert.Equal(t, map[string]float64{"connected": 0, "disconnected": 1, "closed": 0}, state())
+
+	c.ConnectionStateChanged(ferstream.ConnectionStateReconnected)
+	assert.Equal(t, map[string]float64{"connected": 1, "disconnected": 0, "closed": 0}, state())
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.reconnects))
+
+	c.ConnectionStateChanged(ferstream.ConnectionStateClosed)
+	assert.Equal(t, map[string]float64{"connected": 0, "disconnected": 0, "closed": 1}, state())
+	assert.Equal(t, 1, testutil.CollectAndCount(c, "test_connection_reconnects_total"))
+}
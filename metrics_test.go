@@ -0,0 +1,119 @@
+package ferstream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordedMetrics a Metrics which counts the events by name
+type recordedMetrics struct {
+	mu     sync.Mutex
+	events map[string]int
+}
+
+func newRecordedMetrics() *recordedMetrics {
+	return &recordedMetrics{events: map[string]int{}}
+}
+
+func (m *recordedMetrics) record(event string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events[event]++
+}
+
+func (m *recordedMetrics) count(event string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.events[event]
+}
+
+func (m *recordedMetrics) ObservePublish(_ string, _ time.Duration, err error) {
+	if err != nil {
+		m.record("publish error")
+		return
+	}
+	m.record("publish")
+}
+
+func (m *recordedMetrics) MessageReceived(string)                 { m.record("received") }
+func (m *recordedMetrics) MessageParseFailed(string)              { m.record("parse failed") }
+func (m *recordedMetrics) MessageRetried(string)                  { m.record("retried") }
+func (m *recordedMetrics) MessageSucceeded(string, time.Duration) { m.record("succeeded") }
+func (m *recordedMetrics) MessageGivenUp(string, time.Duration)   { m.record("given up") }
+func (m *recordedMetrics) ErrorHandlerFailed(string)              { m.record("error handler failed") }
+func (m *recordedMetrics) ConnectionStateChanged(state ConnectionState) {
+	m.record(string(state))
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := newRecordedMetrics()
+	n, err := NewNATSConnectionWithOptions(defaultURL, nil, WithConnectionMetrics(metrics))
+	require.NoError(t, err)
+	defer SafeClose(n)
+	assert.Equal(t, 1, metrics.count("connected"))
+
+	_, err = n.AddStream(&nats.StreamConfig{
+		Name:     "STREAM_NAME_METRICS",
+		Subjects: []string{"STREAM_NAME_METRICS.*"},
+		Storage:  nats.FileStorage,
+	})
+	require.NoError(t, err)
+
+	subject := "STREAM_NAME_METRICS.TEST"
+	var attempts int
+	msgHandler := func(_ context.Context, payload MessageParser) error {
+		attempts++
+		msg := payload.(*NatsEventMessage)
+		if msg.NatsEvent.GetID() == 1 && attempts == 1 {
+			return assert.AnError
+		}
+		if msg.NatsEvent.GetID() == 2 {
+			return Permanent(assert.AnError)
+		}
+		return nil
+	}
+	errHandler := func(_ context.Context, _ MessageParser) error {
+		return errors.New("error handler failed")
+	}
+
+	sub, err := n.Subscribe(subject, NewMessageHandler(NewNatsEventMessage(), msgHandler,
+		WithRetryPolicy(NewScheduleRetryPolicy(time.Millisecond)),
+		WithErrorHandler(errHandler),
+		WithMetrics(metrics)),
+		nats.Durable("metrics"), nats.ManualAck(), nats.DeliverNew(), nats.MaxAckPending(1))
+	require.NoError(t, err)
+	defer func() { _ = sub.Unsubscribe() }()
+
+	for _, id := range []int64{1, 2} {
+		data, err := NewNatsEventMessage().WithEvent(&NatsEvent{ID: id, UserID: 21}).Build()
+		require.NoError(t, err)
+		_, err = n.Publish(subject, data)
+		require.NoError(t, err)
+	}
+	_, err = n.Publish(subject, []byte("not a json"))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return metrics.count("received") == 3 && metrics.count("given up") == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 3, metrics.count("publish"))
+	assert.Equal(t, 1, metrics.count("retried"))
+	assert.Equal(t, 1, metrics.count("succeeded"))
+	assert.Equal(t, 1, metrics.count("parse failed"))
+	assert.Equal(t, 2, metrics.count("error handler failed"))
+
+	n.GetNATSConnection().Close()
+	_, err = n.Publish(subject, []byte("{}"))
+	assert.ErrorIs(t, err, ErrConnectionLost)
+	assert.Equal(t, 1, metrics.count("publish error"))
+	assert.Eventually(t, func() bool {
+		return metrics.count("closed") == 1
+	}, time.Second, 10*time.Millisecond)
+}
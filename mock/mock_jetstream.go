@@ -1,18 +1,20 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/kumparan/ferstream (interfaces: JetStream)
+// Source: github.com/kumparan/ferstream (interfaces: JetStream,JetStreamV2)
 //
 // Generated by this command:
 //
-//	mockgen -destination=mock/mock_jetstream.go -package=mock github.com/kumparan/ferstream JetStream
+//	mockgen -destination=mock/mock_jetstream.go -package=mock github.com/kumparan/ferstream JetStream,JetStreamV2
 //
 
 // Package mock is a generated GoMock package.
 package mock
 
 import (
+	context "context"
 	reflect "reflect"
 
 	nats "github.com/nats-io/nats.go"
+	jetstream "github.com/nats-io/nats.go/jetstream"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -39,6 +41,26 @@ func (m *MockJetStream) EXPECT() *MockJetStreamMockRecorder {
 	return m.recorder
 }
 
+// AddConsumer mocks base method.
+func (m *MockJetStream) AddConsumer(arg0 string, arg1 *nats.ConsumerConfig, arg2 ...nats.JSOpt) (*nats.ConsumerInfo, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "AddConsumer", varargs...)
+	ret0, _ := ret[0].(*nats.ConsumerInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddConsumer indicates an expected call of AddConsumer.
+func (mr *MockJetStreamMockRecorder) AddConsumer(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddConsumer", reflect.TypeOf((*MockJetStream)(nil).AddConsumer), varargs...)
+}
+
 // AddStream mocks base method.
 func (m *MockJetStream) AddStream(arg0 *nats.StreamConfig, arg1 ...nats.JSOpt) (*nats.StreamInfo, error) {
 	m.ctrl.T.Helper()
@@ -79,6 +101,83 @@ func (mr *MockJetStreamMockRecorder) ConsumerInfo(arg0, arg1 any, arg2 ...any) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumerInfo", reflect.TypeOf((*MockJetStream)(nil).ConsumerInfo), varargs...)
 }
 
+// DeleteConsumer mocks base method.
+func (m *MockJetStream) DeleteConsumer(arg0, arg1 string, arg2 ...nats.JSOpt) error {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteConsumer", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteConsumer indicates an expected call of DeleteConsumer.
+func (mr *MockJetStreamMockRecorder) DeleteConsumer(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteConsumer", reflect.TypeOf((*MockJetStream)(nil).DeleteConsumer), varargs...)
+}
+
+// DeleteMsg mocks base method.
+func (m *MockJetStream) DeleteMsg(arg0 string, arg1 uint64, arg2 ...nats.JSOpt) error {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteMsg", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMsg indicates an expected call of DeleteMsg.
+func (mr *MockJetStreamMockRecorder) DeleteMsg(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMsg", reflect.TypeOf((*MockJetStream)(nil).DeleteMsg), varargs...)
+}
+
+// DeleteStream mocks base method.
+func (m *MockJetStream) DeleteStream(arg0 string, arg1 ...nats.JSOpt) error {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteStream", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteStream indicates an expected call of DeleteStream.
+func (mr *MockJetStreamMockRecorder) DeleteStream(arg0 any, arg1 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStream", reflect.TypeOf((*MockJetStream)(nil).DeleteStream), varargs...)
+}
+
+// EnsureConsumer mocks base method.
+func (m *MockJetStream) EnsureConsumer(arg0 string, arg1 *nats.ConsumerConfig, arg2 ...nats.JSOpt) (*nats.ConsumerInfo, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "EnsureConsumer", varargs...)
+	ret0, _ := ret[0].(*nats.ConsumerInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnsureConsumer indicates an expected call of EnsureConsumer.
+func (mr *MockJetStreamMockRecorder) EnsureConsumer(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnsureConsumer", reflect.TypeOf((*MockJetStream)(nil).EnsureConsumer), varargs...)
+}
+
 // GetNATSConnection mocks base method.
 func (m *MockJetStream) GetNATSConnection() *nats.Conn {
 	m.ctrl.T.Helper()
@@ -93,6 +192,36 @@ func (mr *MockJetStreamMockRecorder) GetNATSConnection() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNATSConnection", reflect.TypeOf((*MockJetStream)(nil).GetNATSConnection))
 }
 
+// ListConsumers mocks base method.
+func (m *MockJetStream) ListConsumers(arg0 context.Context, arg1 string) ([]*nats.ConsumerInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListConsumers", arg0, arg1)
+	ret0, _ := ret[0].([]*nats.ConsumerInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListConsumers indicates an expected call of ListConsumers.
+func (mr *MockJetStreamMockRecorder) ListConsumers(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListConsumers", reflect.TypeOf((*MockJetStream)(nil).ListConsumers), arg0, arg1)
+}
+
+// ListStreams mocks base method.
+func (m *MockJetStream) ListStreams(arg0 context.Context, arg1 string) ([]*nats.StreamInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStreams", arg0, arg1)
+	ret0, _ := ret[0].([]*nats.StreamInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStreams indicates an expected call of ListStreams.
+func (mr *MockJetStreamMockRecorder) ListStreams(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStreams", reflect.TypeOf((*MockJetStream)(nil).ListStreams), arg0, arg1)
+}
+
 // Publish mocks base method.
 func (m *MockJetStream) Publish(arg0 string, arg1 []byte, arg2 ...nats.PubOpt) (*nats.PubAck, error) {
 	m.ctrl.T.Helper()
@@ -113,6 +242,139 @@ func (mr *MockJetStreamMockRecorder) Publish(arg0, arg1 any, arg2 ...any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockJetStream)(nil).Publish), varargs...)
 }
 
+// PublishAsync mocks base method.
+func (m *MockJetStream) PublishAsync(arg0 string, arg1 []byte, arg2 ...nats.PubOpt) (nats.PubAckFuture, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PublishAsync", varargs...)
+	ret0, _ := ret[0].(nats.PubAckFuture)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishAsync indicates an expected call of PublishAsync.
+func (mr *MockJetStreamMockRecorder) PublishAsync(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishAsync", reflect.TypeOf((*MockJetStream)(nil).PublishAsync), varargs...)
+}
+
+// PublishAsyncComplete mocks base method.
+func (m *MockJetStream) PublishAsyncComplete(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishAsyncComplete", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishAsyncComplete indicates an expected call of PublishAsyncComplete.
+func (mr *MockJetStreamMockRecorder) PublishAsyncComplete(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishAsyncComplete", reflect.TypeOf((*MockJetStream)(nil).PublishAsyncComplete), arg0)
+}
+
+// PublishMsg mocks base method.
+func (m *MockJetStream) PublishMsg(arg0 *nats.Msg, arg1 ...nats.PubOpt) (*nats.PubAck, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PublishMsg", varargs...)
+	ret0, _ := ret[0].(*nats.PubAck)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishMsg indicates an expected call of PublishMsg.
+func (mr *MockJetStreamMockRecorder) PublishMsg(arg0 any, arg1 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishMsg", reflect.TypeOf((*MockJetStream)(nil).PublishMsg), varargs...)
+}
+
+// PublishMsgAsync mocks base method.
+func (m *MockJetStream) PublishMsgAsync(arg0 *nats.Msg, arg1 ...nats.PubOpt) (nats.PubAckFuture, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PublishMsgAsync", varargs...)
+	ret0, _ := ret[0].(nats.PubAckFuture)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishMsgAsync indicates an expected call of PublishMsgAsync.
+func (mr *MockJetStreamMockRecorder) PublishMsgAsync(arg0 any, arg1 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishMsgAsync", reflect.TypeOf((*MockJetStream)(nil).PublishMsgAsync), varargs...)
+}
+
+// PublishMsgWithContext mocks base method.
+func (m *MockJetStream) PublishMsgWithContext(arg0 context.Context, arg1 *nats.Msg, arg2 ...nats.PubOpt) (*nats.PubAck, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PublishMsgWithContext", varargs...)
+	ret0, _ := ret[0].(*nats.PubAck)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishMsgWithContext indicates an expected call of PublishMsgWithContext.
+func (mr *MockJetStreamMockRecorder) PublishMsgWithContext(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishMsgWithContext", reflect.TypeOf((*MockJetStream)(nil).PublishMsgWithContext), varargs...)
+}
+
+// PullSubscribe mocks base method.
+func (m *MockJetStream) PullSubscribe(arg0, arg1 string, arg2 ...nats.SubOpt) (*nats.Subscription, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PullSubscribe", varargs...)
+	ret0, _ := ret[0].(*nats.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PullSubscribe indicates an expected call of PullSubscribe.
+func (mr *MockJetStreamMockRecorder) PullSubscribe(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PullSubscribe", reflect.TypeOf((*MockJetStream)(nil).PullSubscribe), varargs...)
+}
+
+// PurgeStream mocks base method.
+func (m *MockJetStream) PurgeStream(arg0 string, arg1 ...nats.JSOpt) error {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PurgeStream", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeStream indicates an expected call of PurgeStream.
+func (mr *MockJetStreamMockRecorder) PurgeStream(arg0 any, arg1 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeStream", reflect.TypeOf((*MockJetStream)(nil).PurgeStream), varargs...)
+}
+
 // QueueSubscribe mocks base method.
 func (m *MockJetStream) QueueSubscribe(arg0, arg1 string, arg2 nats.MsgHandler, arg3 ...nats.SubOpt) (*nats.Subscription, error) {
 	m.ctrl.T.Helper()
@@ -133,6 +395,41 @@ func (mr *MockJetStreamMockRecorder) QueueSubscribe(arg0, arg1, arg2 any, arg3 .
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueueSubscribe", reflect.TypeOf((*MockJetStream)(nil).QueueSubscribe), varargs...)
 }
 
+// StreamInfo mocks base method.
+func (m *MockJetStream) StreamInfo(arg0 string, arg1 ...nats.JSOpt) (*nats.StreamInfo, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "StreamInfo", varargs...)
+	ret0, _ := ret[0].(*nats.StreamInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StreamInfo indicates an expected call of StreamInfo.
+func (mr *MockJetStreamMockRecorder) StreamInfo(arg0 any, arg1 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamInfo", reflect.TypeOf((*MockJetStream)(nil).StreamInfo), varargs...)
+}
+
+// StreamNames mocks base method.
+func (m *MockJetStream) StreamNames(arg0 context.Context, arg1 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamNames", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StreamNames indicates an expected call of StreamNames.
+func (mr *MockJetStreamMockRecorder) StreamNames(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamNames", reflect.TypeOf((*MockJetStream)(nil).StreamNames), arg0, arg1)
+}
+
 // Subscribe mocks base method.
 func (m *MockJetStream) Subscribe(arg0 string, arg1 nats.MsgHandler, arg2 ...nats.SubOpt) (*nats.Subscription, error) {
 	m.ctrl.T.Helper()
@@ -152,3 +449,699 @@ func (mr *MockJetStreamMockRecorder) Subscribe(arg0, arg1 any, arg2 ...any) *gom
 	varargs := append([]any{arg0, arg1}, arg2...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockJetStream)(nil).Subscribe), varargs...)
 }
+
+// UpdateConsumer mocks base method.
+func (m *MockJetStream) UpdateConsumer(arg0 string, arg1 *nats.ConsumerConfig, arg2 ...nats.JSOpt) (*nats.ConsumerInfo, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UpdateConsumer", varargs...)
+	ret0, _ := ret[0].(*nats.ConsumerInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateConsumer indicates an expected call of UpdateConsumer.
+func (mr *MockJetStreamMockRecorder) UpdateConsumer(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateConsumer", reflect.TypeOf((*MockJetStream)(nil).UpdateConsumer), varargs...)
+}
+
+// UpdateStream mocks base method.
+func (m *MockJetStream) UpdateStream(arg0 *nats.StreamConfig, arg1 ...nats.JSOpt) (*nats.StreamInfo, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UpdateStream", varargs...)
+	ret0, _ := ret[0].(*nats.StreamInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateStream indicates an expected call of UpdateStream.
+func (mr *MockJetStreamMockRecorder) UpdateStream(arg0 any, arg1 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStream", reflect.TypeOf((*MockJetStream)(nil).UpdateStream), varargs...)
+}
+
+// MockJetStreamV2 is a mock of JetStreamV2 interface.
+type MockJetStreamV2 struct {
+	ctrl     *gomock.Controller
+	recorder *MockJetStreamV2MockRecorder
+}
+
+// MockJetStreamV2MockRecorder is the mock recorder for MockJetStreamV2.
+type MockJetStreamV2MockRecorder struct {
+	mock *MockJetStreamV2
+}
+
+// NewMockJetStreamV2 creates a new mock instance.
+func NewMockJetStreamV2(ctrl *gomock.Controller) *MockJetStreamV2 {
+	mock := &MockJetStreamV2{ctrl: ctrl}
+	mock.recorder = &MockJetStreamV2MockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJetStreamV2) EXPECT() *MockJetStreamV2MockRecorder {
+	return m.recorder
+}
+
+// AddConsumer mocks base method.
+func (m *MockJetStreamV2) AddConsumer(arg0 string, arg1 *nats.ConsumerConfig, arg2 ...nats.JSOpt) (*nats.ConsumerInfo, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "AddConsumer", varargs...)
+	ret0, _ := ret[0].(*nats.ConsumerInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddConsumer indicates an expected call of AddConsumer.
+func (mr *MockJetStreamV2MockRecorder) AddConsumer(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddConsumer", reflect.TypeOf((*MockJetStreamV2)(nil).AddConsumer), varargs...)
+}
+
+// AddStream mocks base method.
+func (m *MockJetStreamV2) AddStream(arg0 *nats.StreamConfig, arg1 ...nats.JSOpt) (*nats.StreamInfo, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "AddStream", varargs...)
+	ret0, _ := ret[0].(*nats.StreamInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddStream indicates an expected call of AddStream.
+func (mr *MockJetStreamV2MockRecorder) AddStream(arg0 any, arg1 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddStream", reflect.TypeOf((*MockJetStreamV2)(nil).AddStream), varargs...)
+}
+
+// Consume mocks base method.
+func (m *MockJetStreamV2) Consume(arg0 jetstream.Consumer, arg1 nats.MsgHandler, arg2 ...jetstream.PullConsumeOpt) (jetstream.ConsumeContext, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Consume", varargs...)
+	ret0, _ := ret[0].(jetstream.ConsumeContext)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Consume indicates an expected call of Consume.
+func (mr *MockJetStreamV2MockRecorder) Consume(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consume", reflect.TypeOf((*MockJetStreamV2)(nil).Consume), varargs...)
+}
+
+// Consumer mocks base method.
+func (m *MockJetStreamV2) Consumer(arg0 context.Context, arg1, arg2 string) (jetstream.Consumer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Consumer", arg0, arg1, arg2)
+	ret0, _ := ret[0].(jetstream.Consumer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Consumer indicates an expected call of Consumer.
+func (mr *MockJetStreamV2MockRecorder) Consumer(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consumer", reflect.TypeOf((*MockJetStreamV2)(nil).Consumer), arg0, arg1, arg2)
+}
+
+// ConsumerInfo mocks base method.
+func (m *MockJetStreamV2) ConsumerInfo(arg0, arg1 string, arg2 ...nats.JSOpt) (*nats.ConsumerInfo, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ConsumerInfo", varargs...)
+	ret0, _ := ret[0].(*nats.ConsumerInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumerInfo indicates an expected call of ConsumerInfo.
+func (mr *MockJetStreamV2MockRecorder) ConsumerInfo(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumerInfo", reflect.TypeOf((*MockJetStreamV2)(nil).ConsumerInfo), varargs...)
+}
+
+// ConsumersInfo mocks base method.
+func (m *MockJetStreamV2) ConsumersInfo(arg0 context.Context, arg1 string) ([]*jetstream.ConsumerInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumersInfo", arg0, arg1)
+	ret0, _ := ret[0].([]*jetstream.ConsumerInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumersInfo indicates an expected call of ConsumersInfo.
+func (mr *MockJetStreamV2MockRecorder) ConsumersInfo(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumersInfo", reflect.TypeOf((*MockJetStreamV2)(nil).ConsumersInfo), arg0, arg1)
+}
+
+// CreateOrUpdateConsumer mocks base method.
+func (m *MockJetStreamV2) CreateOrUpdateConsumer(arg0 context.Context, arg1 string, arg2 jetstream.ConsumerConfig) (jetstream.Consumer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrUpdateConsumer", arg0, arg1, arg2)
+	ret0, _ := ret[0].(jetstream.Consumer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrUpdateConsumer indicates an expected call of CreateOrUpdateConsumer.
+func (mr *MockJetStreamV2MockRecorder) CreateOrUpdateConsumer(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrUpdateConsumer", reflect.TypeOf((*MockJetStreamV2)(nil).CreateOrUpdateConsumer), arg0, arg1, arg2)
+}
+
+// CreateOrUpdateStream mocks base method.
+func (m *MockJetStreamV2) CreateOrUpdateStream(arg0 context.Context, arg1 jetstream.StreamConfig) (jetstream.Stream, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrUpdateStream", arg0, arg1)
+	ret0, _ := ret[0].(jetstream.Stream)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrUpdateStream indicates an expected call of CreateOrUpdateStream.
+func (mr *MockJetStreamV2MockRecorder) CreateOrUpdateStream(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrUpdateStream", reflect.TypeOf((*MockJetStreamV2)(nil).CreateOrUpdateStream), arg0, arg1)
+}
+
+// DeleteConsumer mocks base method.
+func (m *MockJetStreamV2) DeleteConsumer(arg0, arg1 string, arg2 ...nats.JSOpt) error {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteConsumer", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteConsumer indicates an expected call of DeleteConsumer.
+func (mr *MockJetStreamV2MockRecorder) DeleteConsumer(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteConsumer", reflect.TypeOf((*MockJetStreamV2)(nil).DeleteConsumer), varargs...)
+}
+
+// DeleteMsg mocks base method.
+func (m *MockJetStreamV2) DeleteMsg(arg0 string, arg1 uint64, arg2 ...nats.JSOpt) error {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteMsg", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMsg indicates an expected call of DeleteMsg.
+func (mr *MockJetStreamV2MockRecorder) DeleteMsg(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMsg", reflect.TypeOf((*MockJetStreamV2)(nil).DeleteMsg), varargs...)
+}
+
+// DeleteStream mocks base method.
+func (m *MockJetStreamV2) DeleteStream(arg0 string, arg1 ...nats.JSOpt) error {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteStream", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteStream indicates an expected call of DeleteStream.
+func (mr *MockJetStreamV2MockRecorder) DeleteStream(arg0 any, arg1 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStream", reflect.TypeOf((*MockJetStreamV2)(nil).DeleteStream), varargs...)
+}
+
+// EnsureConsumer mocks base method.
+func (m *MockJetStreamV2) EnsureConsumer(arg0 string, arg1 *nats.ConsumerConfig, arg2 ...nats.JSOpt) (*nats.ConsumerInfo, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "EnsureConsumer", varargs...)
+	ret0, _ := ret[0].(*nats.ConsumerInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnsureConsumer indicates an expected call of EnsureConsumer.
+func (mr *MockJetStreamV2MockRecorder) EnsureConsumer(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnsureConsumer", reflect.TypeOf((*MockJetStreamV2)(nil).EnsureConsumer), varargs...)
+}
+
+// GetJetStream mocks base method.
+func (m *MockJetStreamV2) GetJetStream() jetstream.JetStream {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJetStream")
+	ret0, _ := ret[0].(jetstream.JetStream)
+	return ret0
+}
+
+// GetJetStream indicates an expected call of GetJetStream.
+func (mr *MockJetStreamV2MockRecorder) GetJetStream() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJetStream", reflect.TypeOf((*MockJetStreamV2)(nil).GetJetStream))
+}
+
+// GetNATSConnection mocks base method.
+func (m *MockJetStreamV2) GetNATSConnection() *nats.Conn {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNATSConnection")
+	ret0, _ := ret[0].(*nats.Conn)
+	return ret0
+}
+
+// GetNATSConnection indicates an expected call of GetNATSConnection.
+func (mr *MockJetStreamV2MockRecorder) GetNATSConnection() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNATSConnection", reflect.TypeOf((*MockJetStreamV2)(nil).GetNATSConnection))
+}
+
+// ListConsumers mocks base method.
+func (m *MockJetStreamV2) ListConsumers(arg0 context.Context, arg1 string) ([]*nats.ConsumerInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListConsumers", arg0, arg1)
+	ret0, _ := ret[0].([]*nats.ConsumerInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListConsumers indicates an expected call of ListConsumers.
+func (mr *MockJetStreamV2MockRecorder) ListConsumers(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListConsumers", reflect.TypeOf((*MockJetStreamV2)(nil).ListConsumers), arg0, arg1)
+}
+
+// ListStreams mocks base method.
+func (m *MockJetStreamV2) ListStreams(arg0 context.Context, arg1 string) ([]*nats.StreamInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStreams", arg0, arg1)
+	ret0, _ := ret[0].([]*nats.StreamInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStreams indicates an expected call of ListStreams.
+func (mr *MockJetStreamV2MockRecorder) ListStreams(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStreams", reflect.TypeOf((*MockJetStreamV2)(nil).ListStreams), arg0, arg1)
+}
+
+// OrderedConsumer mocks base method.
+func (m *MockJetStreamV2) OrderedConsumer(arg0 context.Context, arg1 string, arg2 jetstream.OrderedConsumerConfig) (jetstream.Consumer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OrderedConsumer", arg0, arg1, arg2)
+	ret0, _ := ret[0].(jetstream.Consumer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OrderedConsumer indicates an expected call of OrderedConsumer.
+func (mr *MockJetStreamV2MockRecorder) OrderedConsumer(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OrderedConsumer", reflect.TypeOf((*MockJetStreamV2)(nil).OrderedConsumer), arg0, arg1, arg2)
+}
+
+// Publish mocks base method.
+func (m *MockJetStreamV2) Publish(arg0 string, arg1 []byte, arg2 ...nats.PubOpt) (*nats.PubAck, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Publish", varargs...)
+	ret0, _ := ret[0].(*nats.PubAck)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockJetStreamV2MockRecorder) Publish(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockJetStreamV2)(nil).Publish), varargs...)
+}
+
+// PublishAsync mocks base method.
+func (m *MockJetStreamV2) PublishAsync(arg0 string, arg1 []byte, arg2 ...nats.PubOpt) (nats.PubAckFuture, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PublishAsync", varargs...)
+	ret0, _ := ret[0].(nats.PubAckFuture)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishAsync indicates an expected call of PublishAsync.
+func (mr *MockJetStreamV2MockRecorder) PublishAsync(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishAsync", reflect.TypeOf((*MockJetStreamV2)(nil).PublishAsync), varargs...)
+}
+
+// PublishAsyncComplete mocks base method.
+func (m *MockJetStreamV2) PublishAsyncComplete(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishAsyncComplete", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishAsyncComplete indicates an expected call of PublishAsyncComplete.
+func (mr *MockJetStreamV2MockRecorder) PublishAsyncComplete(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishAsyncComplete", reflect.TypeOf((*MockJetStreamV2)(nil).PublishAsyncComplete), arg0)
+}
+
+// PublishContext mocks base method.
+func (m *MockJetStreamV2) PublishContext(arg0 context.Context, arg1 string, arg2 []byte, arg3 ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PublishContext", varargs...)
+	ret0, _ := ret[0].(*jetstream.PubAck)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishContext indicates an expected call of PublishContext.
+func (mr *MockJetStreamV2MockRecorder) PublishContext(arg0, arg1, arg2 any, arg3 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishContext", reflect.TypeOf((*MockJetStreamV2)(nil).PublishContext), varargs...)
+}
+
+// PublishMsg mocks base method.
+func (m *MockJetStreamV2) PublishMsg(arg0 *nats.Msg, arg1 ...nats.PubOpt) (*nats.PubAck, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PublishMsg", varargs...)
+	ret0, _ := ret[0].(*nats.PubAck)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishMsg indicates an expected call of PublishMsg.
+func (mr *MockJetStreamV2MockRecorder) PublishMsg(arg0 any, arg1 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishMsg", reflect.TypeOf((*MockJetStreamV2)(nil).PublishMsg), varargs...)
+}
+
+// PublishMsgAsync mocks base method.
+func (m *MockJetStreamV2) PublishMsgAsync(arg0 *nats.Msg, arg1 ...nats.PubOpt) (nats.PubAckFuture, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PublishMsgAsync", varargs...)
+	ret0, _ := ret[0].(nats.PubAckFuture)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishMsgAsync indicates an expected call of PublishMsgAsync.
+func (mr *MockJetStreamV2MockRecorder) PublishMsgAsync(arg0 any, arg1 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishMsgAsync", reflect.TypeOf((*MockJetStreamV2)(nil).PublishMsgAsync), varargs...)
+}
+
+// PublishMsgContext mocks base method.
+func (m *MockJetStreamV2) PublishMsgContext(arg0 context.Context, arg1 *nats.Msg, arg2 ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PublishMsgContext", varargs...)
+	ret0, _ := ret[0].(*jetstream.PubAck)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishMsgContext indicates an expected call of PublishMsgContext.
+func (mr *MockJetStreamV2MockRecorder) PublishMsgContext(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishMsgContext", reflect.TypeOf((*MockJetStreamV2)(nil).PublishMsgContext), varargs...)
+}
+
+// PublishMsgWithContext mocks base method.
+func (m *MockJetStreamV2) PublishMsgWithContext(arg0 context.Context, arg1 *nats.Msg, arg2 ...nats.PubOpt) (*nats.PubAck, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PublishMsgWithContext", varargs...)
+	ret0, _ := ret[0].(*nats.PubAck)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishMsgWithContext indicates an expected call of PublishMsgWithContext.
+func (mr *MockJetStreamV2MockRecorder) PublishMsgWithContext(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishMsgWithContext", reflect.TypeOf((*MockJetStreamV2)(nil).PublishMsgWithContext), varargs...)
+}
+
+// PullSubscribe mocks base method.
+func (m *MockJetStreamV2) PullSubscribe(arg0, arg1 string, arg2 ...nats.SubOpt) (*nats.Subscription, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PullSubscribe", varargs...)
+	ret0, _ := ret[0].(*nats.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PullSubscribe indicates an expected call of PullSubscribe.
+func (mr *MockJetStreamV2MockRecorder) PullSubscribe(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PullSubscribe", reflect.TypeOf((*MockJetStreamV2)(nil).PullSubscribe), varargs...)
+}
+
+// PurgeStream mocks base method.
+func (m *MockJetStreamV2) PurgeStream(arg0 string, arg1 ...nats.JSOpt) error {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PurgeStream", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeStream indicates an expected call of PurgeStream.
+func (mr *MockJetStreamV2MockRecorder) PurgeStream(arg0 any, arg1 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeStream", reflect.TypeOf((*MockJetStreamV2)(nil).PurgeStream), varargs...)
+}
+
+// QueueSubscribe mocks base method.
+func (m *MockJetStreamV2) QueueSubscribe(arg0, arg1 string, arg2 nats.MsgHandler, arg3 ...nats.SubOpt) (*nats.Subscription, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueueSubscribe", varargs...)
+	ret0, _ := ret[0].(*nats.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueueSubscribe indicates an expected call of QueueSubscribe.
+func (mr *MockJetStreamV2MockRecorder) QueueSubscribe(arg0, arg1, arg2 any, arg3 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueueSubscribe", reflect.TypeOf((*MockJetStreamV2)(nil).QueueSubscribe), varargs...)
+}
+
+// Stream mocks base method.
+func (m *MockJetStreamV2) Stream(arg0 context.Context, arg1 string) (jetstream.Stream, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stream", arg0, arg1)
+	ret0, _ := ret[0].(jetstream.Stream)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stream indicates an expected call of Stream.
+func (mr *MockJetStreamV2MockRecorder) Stream(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stream", reflect.TypeOf((*MockJetStreamV2)(nil).Stream), arg0, arg1)
+}
+
+// StreamInfo mocks base method.
+func (m *MockJetStreamV2) StreamInfo(arg0 string, arg1 ...nats.JSOpt) (*nats.StreamInfo, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "StreamInfo", varargs...)
+	ret0, _ := ret[0].(*nats.StreamInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StreamInfo indicates an expected call of StreamInfo.
+func (mr *MockJetStreamV2MockRecorder) StreamInfo(arg0 any, arg1 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamInfo", reflect.TypeOf((*MockJetStreamV2)(nil).StreamInfo), varargs...)
+}
+
+// StreamNames mocks base method.
+func (m *MockJetStreamV2) StreamNames(arg0 context.Context, arg1 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamNames", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StreamNames indicates an expected call of StreamNames.
+func (mr *MockJetStreamV2MockRecorder) StreamNames(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamNames", reflect.TypeOf((*MockJetStreamV2)(nil).StreamNames), arg0, arg1)
+}
+
+// StreamsInfo mocks base method.
+func (m *MockJetStreamV2) StreamsInfo(arg0 context.Context, arg1 ...jetstream.StreamListOpt) ([]*jetstream.StreamInfo, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "StreamsInfo", varargs...)
+	ret0, _ := ret[0].([]*jetstream.StreamInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StreamsInfo indicates an expected call of StreamsInfo.
+func (mr *MockJetStreamV2MockRecorder) StreamsInfo(arg0 any, arg1 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamsInfo", reflect.TypeOf((*MockJetStreamV2)(nil).StreamsInfo), varargs...)
+}
+
+// Subscribe mocks base method.
+func (m *MockJetStreamV2) Subscribe(arg0 string, arg1 nats.MsgHandler, arg2 ...nats.SubOpt) (*nats.Subscription, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Subscribe", varargs...)
+	ret0, _ := ret[0].(*nats.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockJetStreamV2MockRecorder) Subscribe(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockJetStreamV2)(nil).Subscribe), varargs...)
+}
+
+// UpdateConsumer mocks base method.
+func (m *MockJetStreamV2) UpdateConsumer(arg0 string, arg1 *nats.ConsumerConfig, arg2 ...nats.JSOpt) (*nats.ConsumerInfo, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UpdateConsumer", varargs...)
+	ret0, _ := ret[0].(*nats.ConsumerInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateConsumer indicates an expected call of UpdateConsumer.
+func (mr *MockJetStreamV2MockRecorder) UpdateConsumer(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateConsumer", reflect.TypeOf((*MockJetStreamV2)(nil).UpdateConsumer), varargs...)
+}
+
+// UpdateStream mocks base method.
+func (m *MockJetStreamV2) UpdateStream(arg0 *nats.StreamConfig, arg1 ...nats.JSOpt) (*nats.StreamInfo, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UpdateStream", varargs...)
+	ret0, _ := ret[0].(*nats.StreamInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateStream indicates an expected call of UpdateStream.
+func (mr *MockJetStreamV2MockRecorder) UpdateStream(arg0 any, arg1 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStream", reflect.TypeOf((*MockJetStreamV2)(nil).UpdateStream), varargs...)
+}
@@ -0,0 +1,127 @@
+// Package outbox implements the transactional outbox: the events are written in the business transaction
+// and published by a Relay afterwards, so they are not lost when the process dies between the commit and the publish
+package outbox
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kumparan/ferstream"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nuid"
+)
+
+type (
+	// Message an event waiting in the outbox to be published
+	Message struct {
+		// ID the nats.MsgId of the publish, the stream drops a message published again with the same ID
+		// within its Duplicates window
+		ID        string
+		Subject   string
+		Data      []byte
+		Header    nats.Header
+		CreatedAt time.Time
+	}
+
+	// OutboxStore the outbox read by the Relay, see SQLStore to write the messages in a database/sql transaction
+	OutboxStore interface {
+		// Pending returns up to limit messages which are not sent yet, the oldest first.
+		// The messages are not claimed, the Relay reading them is the only one of the store.
+		Pending(ctx context.Context, limit int) ([]*Message, error)
+		// MarkSent marks the messages as sent, so they are not returned by Pending anymore
+		MarkSent(ctx context.Context, ids []string) error
+	}
+
+	// MemoryStore an in-memory OutboxStore, e.g. for tests
+	MemoryStore struct {
+		mu       sync.Mutex
+		messages []*Message
+		sent     map[string]bool
+	}
+)
+
+// NewMessage builds the outbox message of event with its headers, see ferstream.NatsEventMessage.BuildMsg
+func NewMessage(subject string, event *ferstream.NatsEventMessage) (*Message, error) {
+	msg, err := event.BuildMsg(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		ID:        nuid.Next(),
+		Subject:   subject,
+		Data:      msg.Data,
+		Header:    msg.Header,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// natsMsg the message to publish
+func (m *Message) natsMsg() *nats.Msg {
+	return &nats.Msg{
+		Subject: m.Subject,
+		Data:    m.Data,
+		Header:  m.Header,
+	}
+}
+
+// NewMemoryStore :nodoc:
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sent: map[string]bool{}}
+}
+
+// Add adds the message to the outbox
+func (s *MemoryStore) Add(msg *Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+}
+
+// Pending implements OutboxStore
+func (s *MemoryStore) Pending(_ context.Context, limit int) ([]*Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pending []*Message
+	for _, msg := range s.messages {
+		if len(pending) == limit {
+			break
+		}
+		if !s.sent[msg.ID] {
+			pending = append(pending, msg)
+		}
+	}
+	return pending, nil
+}
+
+// MarkSent implements OutboxStore
+func (s *MemoryStore) MarkSent(_ context.Context, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		s.sent[id] = true
+	}
+	return nil
+}
+
+// Sent returns true when the message is marked sent
+func (s *MemoryStore) Sent(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sent[id]
+}
+
+// Len returns the number of messages which are not sent yet
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, msg := range s.messages {
+		if !s.sent[msg.ID] {
+			n++
+		}
+	}
+	return n
+}
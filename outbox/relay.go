@@ -0,0 +1,121 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kumparan/ferstream"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	defaultRelayInterval  = time.Second
+	defaultRelayBatchSize = 100
+)
+
+type (
+	// Relay publishes the pending messages of an OutboxStore and marks them sent.
+	// Pending does not claim the messages, so only one Relay may run per store, e.g. on the leader of the replicas,
+	// otherwise the relays publish the same messages and their order is lost.
+	Relay struct {
+		store OutboxStore
+		js    ferstream.JetStream
+		opts  relayOptions
+	}
+
+	// RelayOption optional configuration of NewRelay
+	RelayOption func(*relayOptions)
+
+	relayOptions struct {
+		interval     time.Duration
+		batchSize    int
+		errorHandler func(err error)
+	}
+)
+
+// WithInterval sets how long Run waits before polling the store again once every pending message is published
+func WithInterval(d time.Duration) RelayOption {
+	return func(o *relayOptions) {
+		o.interval = d
+	}
+}
+
+// WithBatchSize sets the number of pending messages read from the store at once
+func WithBatchSize(n int) RelayOption {
+	return func(o *relayOptions) {
+		o.batchSize = n
+	}
+}
+
+// WithErrorHandler sets the callback of the errors of Run, which keeps running after them
+func WithErrorHandler(handler func(err error)) RelayOption {
+	return func(o *relayOptions) {
+		o.errorHandler = handler
+	}
+}
+
+// NewRelay :nodoc:
+func NewRelay(store OutboxStore, js ferstream.JetStream, opts ...RelayOption) *Relay {
+	r := &Relay{
+		store: store,
+		js:    js,
+		opts: relayOptions{
+			interval:     defaultRelayInterval,
+			batchSize:    defaultRelayBatchSize,
+			errorHandler: func(error) {},
+		},
+	}
+	for _, opt := range opts {
+		opt(&r.opts)
+	}
+	return r
+}
+
+// Run relays the pending messages until ctx is done, e.g. in its own goroutine
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		sent, err := r.RelayPending(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.opts.errorHandler(err)
+		}
+
+		// a full batch means more messages may be pending
+		wait := r.opts.interval
+		if err == nil && sent == r.opts.batchSize {
+			wait = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// RelayPending publishes a batch of pending messages with their ID as nats.MsgId and marks the published ones sent.
+// It stops at the first failed publish to keep the order of the messages, the publish error is returned along with
+// the error of MarkSent when the published ones can not be marked sent. A message published again,
+// e.g. when the process died before it is marked sent, is dropped by the stream within its Duplicates window.
+func (r *Relay) RelayPending(ctx context.Context) (sent int, err error) {
+	messages, err := r.store.Pending(ctx, r.opts.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		if _, err = r.js.PublishMsg(msg.natsMsg(), nats.MsgId(msg.ID)); err != nil {
+			err = fmt.Errorf("failed to publish outbox message %s: %w", msg.ID, err)
+			break
+		}
+		ids = append(ids, msg.ID)
+	}
+
+	if markErr := r.store.MarkSent(ctx, ids); markErr != nil {
+		return 0, errors.Join(err, markErr)
+	}
+	return len(ids), err
+}
@@ -0,0 +1,143 @@
+package outbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kumparan/ferstream"
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newJetStream connects to an embedded server on a random port, the root package's server uses the default one
+func newJetStream(t *testing.T) ferstream.JetStream {
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+	srv := natsserver.RunServer(&opts)
+	t.Cleanup(srv.Shutdown)
+
+	js, err := ferstream.NewNATSConnection(srv.ClientURL(), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { ferstream.SafeClose(js) })
+
+	_, err = js.AddStream(&nats.StreamConfig{
+		Name:       "OUTBOX",
+		Subjects:   []string{"OUTBOX.*"},
+		Storage:    nats.MemoryStorage,
+		Duplicates: time.Minute,
+	})
+	require.NoError(t, err)
+	return js
+}
+
+func newEventMessage(t *testing.T, id int64) *Message {
+	msg, err := NewMessage("OUTBOX.CREATED", ferstream.NewNatsEventMessage().
+		WithEvent(&ferstream.NatsEvent{ID: id, UserID: 2}).
+		WithEventType("created"))
+	require.NoError(t, err)
+	return msg
+}
+
+func TestRelay_RelayPending(t *testing.T) {
+	ctx := context.Background()
+	js := newJetStream(t)
+
+	messages := []*Message{newEventMessage(t, 1), newEventMessage(t, 2), newEventMessage(t, 3)}
+	store := NewMemoryStore()
+	for _, msg := range messages {
+		store.Add(msg)
+	}
+
+	sent, err := NewRelay(store, js).RelayPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, sent)
+	assert.Equal(t, 0, store.Len())
+
+	info, err := js.StreamInfo("OUTBOX")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), info.State.Msgs)
+
+	t.Run("published again before marked sent", func(t *testing.T) {
+		store := NewMemoryStore()
+		store.Add(messages[0])
+
+		sent, err := NewRelay(store, js).RelayPending(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+
+		info, err := js.StreamInfo("OUTBOX")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(3), info.State.Msgs)
+	})
+
+	t.Run("stop at the first failed publish", func(t *testing.T) {
+		store := NewMemoryStore()
+		published := newEventMessage(t, 4)
+		failed := &Message{ID: "no-stream", Subject: "NO_STREAM", Data: []byte("{}")}
+		notPublished := newEventMessage(t, 5)
+		store.Add(published)
+		store.Add(failed)
+		store.Add(notPublished)
+
+		sent, err := NewRelay(store, js).RelayPending(ctx)
+		assert.ErrorIs(t, err, nats.ErrNoStreamResponse)
+		assert.Equal(t, 1, sent)
+		assert.True(t, store.Sent(published.ID))
+		assert.False(t, store.Sent(failed.ID))
+		assert.False(t, store.Sent(notPublished.ID))
+	})
+
+	t.Run("publish and mark sent failed", func(t *testing.T) {
+		store := &markSentFailingStore{MemoryStore: NewMemoryStore()}
+		store.Add(newEventMessage(t, 6))
+		store.Add(&Message{ID: "no-stream", Subject: "NO_STREAM", Data: []byte("{}")})
+
+		sent, err := NewRelay(store, js).RelayPending(ctx)
+		assert.ErrorIs(t, err, nats.ErrNoStreamResponse)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 0, sent)
+	})
+}
+
+// markSentFailingStore a MemoryStore which fails to mark the messages sent
+type markSentFailingStore struct {
+	*MemoryStore
+}
+
+func (s *markSentFailingStore) MarkSent(context.Context, []string) error {
+	return assert.AnError
+}
+
+func TestRelay_Run(t *testing.T) {
+	js := newJetStream(t)
+	store := NewMemoryStore()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- NewRelay(store, js, WithInterval(10*time.Millisecond), WithBatchSize(1),
+			WithErrorHandler(func(err error) { t.Error(err) })).Run(ctx)
+	}()
+
+	for _, id := range []int64{1, 2} {
+		store.Add(newEventMessage(t, id))
+	}
+
+	assert.Eventually(t, func() bool {
+		return store.Len() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	jsCtx, err := js.GetNATSConnection().JetStream()
+	require.NoError(t, err)
+	stored, err := jsCtx.GetMsg("OUTBOX", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "created", stored.Header.Get(ferstream.HeaderEventType))
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
@@ -0,0 +1,145 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultTable default table of SQLStore
+const DefaultTable = "ferstream_outbox"
+
+// Placeholder the bind parameter style of the database
+type Placeholder int
+
+// placeholders
+const (
+	// PlaceholderQuestion ?, e.g. MySQL and SQLite
+	PlaceholderQuestion Placeholder = iota
+	// PlaceholderDollar $1, e.g. PostgreSQL
+	PlaceholderDollar
+)
+
+type (
+	// Execer a *sql.Tx of the business transaction, or a *sql.DB
+	Execer interface {
+		ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	}
+
+	// SQLStore an OutboxStore in a database/sql table with the columns:
+	//
+	//	id         VARCHAR(64) PRIMARY KEY
+	//	subject    VARCHAR(255) NOT NULL
+	//	data       BLOB (BYTEA in PostgreSQL) NOT NULL
+	//	header     TEXT NOT NULL
+	//	created_at TIMESTAMP NOT NULL
+	//	sent_at    TIMESTAMP NULL
+	//
+	// with an index on (sent_at, created_at) for Pending
+	SQLStore struct {
+		db          *sql.DB
+		table       string
+		placeholder Placeholder
+	}
+
+	// SQLStoreOption optional configuration of NewSQLStore
+	SQLStoreOption func(*SQLStore)
+)
+
+// WithTable sets the table of the outbox, default to DefaultTable
+func WithTable(table string) SQLStoreOption {
+	return func(s *SQLStore) {
+		s.table = table
+	}
+}
+
+// WithPlaceholder sets the bind parameter style of the database, default to PlaceholderQuestion
+func WithPlaceholder(placeholder Placeholder) SQLStoreOption {
+	return func(s *SQLStore) {
+		s.placeholder = placeholder
+	}
+}
+
+// NewSQLStore :nodoc:
+func NewSQLStore(db *sql.DB, opts ...SQLStoreOption) *SQLStore {
+	s := &SQLStore{
+		db:    db,
+		table: DefaultTable,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Add writes the message with tx, the transaction of the business change, so the message is only published once it is committed
+func (s *SQLStore) Add(ctx context.Context, tx Execer, msg *Message) error {
+	header, err := json.Marshal(msg.Header)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal header")
+	}
+
+	query := "INSERT INTO " + s.table + " (id, subject, data, header, created_at) VALUES (" + s.placeholders(1, 5) + ")"
+	_, err = tx.ExecContext(ctx, query, msg.ID, msg.Subject, msg.Data, string(header), msg.CreatedAt.UTC())
+	return errors.Wrap(err, "failed to add outbox message")
+}
+
+// Pending implements OutboxStore, it reads the rows without locking them, so only one Relay may run on the table
+func (s *SQLStore) Pending(ctx context.Context, limit int) ([]*Message, error) {
+	query := "SELECT id, subject, data, header, created_at FROM " + s.table +
+		" WHERE sent_at IS NULL ORDER BY created_at, id LIMIT " + strconv.Itoa(limit)
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query pending outbox messages")
+	}
+	defer func() { _ = rows.Close() }()
+
+	var messages []*Message
+	for rows.Next() {
+		msg := &Message{}
+		var header string
+		if err := rows.Scan(&msg.ID, &msg.Subject, &msg.Data, &header, &msg.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, "failed to scan outbox message")
+		}
+		if err := json.Unmarshal([]byte(header), &msg.Header); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal header of outbox message %s", msg.ID)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, errors.Wrap(rows.Err(), "failed to read pending outbox messages")
+}
+
+// MarkSent implements OutboxStore
+func (s *SQLStore) MarkSent(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := make([]any, 0, len(ids)+1)
+	args = append(args, time.Now().UTC())
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	query := "UPDATE " + s.table + " SET sent_at = " + s.placeholders(1, 1) +
+		" WHERE id IN (" + s.placeholders(2, len(ids)) + ")"
+	_, err := s.db.ExecContext(ctx, query, args...)
+	return errors.Wrap(err, "failed to mark outbox messages sent")
+}
+
+// placeholders returns n comma separated bind parameters, numbered from first for PlaceholderDollar
+func (s *SQLStore) placeholders(first, n int) string {
+	params := make([]string, n)
+	for i := range params {
+		params[i] = "?"
+		if s.placeholder == PlaceholderDollar {
+			params[i] = "$" + strconv.Itoa(first+i)
+		}
+	}
+	return strings.Join(params, ", ")
+}
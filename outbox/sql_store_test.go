@@ -0,0 +1,12 @@
+package outbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLStore_placeholders(t *testing.T) {
+	assert.Equal(t, "?, ?, ?", NewSQLStore(nil).placeholders(2, 3))
+	assert.Equal(t, "$2, $3, $4", NewSQLStore(nil, WithPlaceholder(PlaceholderDollar)).placeholders(2, 3))
+}
@@ -0,0 +1,3 @@
+// Package sqltest tests the outbox SQLStore on SQLite, it is a module of its own
+// so the cgo driver is not a dependency of ferstream
+package sqltest
@@ -0,0 +1,81 @@
+package sqltest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/kumparan/ferstream/outbox"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSQLiteStore(t *testing.T) (*sql.DB, *outbox.SQLStore) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	// every connection opens its own in-memory database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE outbox (
+		id         VARCHAR(64) PRIMARY KEY,
+		subject    VARCHAR(255) NOT NULL,
+		data       BLOB NOT NULL,
+		header     TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		sent_at    TIMESTAMP NULL
+	)`)
+	require.NoError(t, err)
+
+	return db, outbox.NewSQLStore(db, outbox.WithTable("outbox"))
+}
+
+func TestSQLStore(t *testing.T) {
+	ctx := context.Background()
+	db, store := newSQLiteStore(t)
+
+	add := func(t *testing.T, msg *outbox.Message, commit bool) {
+		tx, err := db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		_, err = tx.ExecContext(ctx, "SELECT 1") // the business change
+		require.NoError(t, err)
+		require.NoError(t, store.Add(ctx, tx, msg))
+		if commit {
+			require.NoError(t, tx.Commit())
+			return
+		}
+		require.NoError(t, tx.Rollback())
+	}
+
+	now := time.Now().Truncate(time.Millisecond)
+	first := &outbox.Message{ID: "1", Subject: "SUBJECT", Data: []byte(`{"id":1}`), Header: nats.Header{"Ferstream-Event-Type": []string{"created"}}, CreatedAt: now}
+	second := &outbox.Message{ID: "2", Subject: "SUBJECT", Data: []byte(`{"id":2}`), CreatedAt: now.Add(time.Second)}
+	add(t, second, true)
+	add(t, first, true)
+	add(t, &outbox.Message{ID: "3", Subject: "SUBJECT", Data: []byte(`{}`), CreatedAt: now}, false)
+
+	pending, err := store.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, first.ID, pending[0].ID)
+	assert.Equal(t, first.Data, pending[0].Data)
+	assert.Equal(t, first.Header, pending[0].Header)
+	assert.True(t, first.CreatedAt.Equal(pending[0].CreatedAt))
+	assert.Equal(t, second.ID, pending[1].ID)
+	assert.Nil(t, pending[1].Header)
+
+	pending, err = store.Pending(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, first.ID, pending[0].ID)
+
+	require.NoError(t, store.MarkSent(ctx, []string{first.ID}))
+	require.NoError(t, store.MarkSent(ctx, nil))
+	pending, err = store.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, second.ID, pending[0].ID)
+}
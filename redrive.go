@@ -0,0 +1,232 @@
+package ferstream
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+)
+
+type (
+	// RedriveFilter returns true when the dead letter message should be moved back to its original subject
+	RedriveFilter func(msg *nats.Msg) bool
+
+	// RedriveOption optional configuration of Redrive
+	RedriveOption func(*redriveOptions)
+
+	redriveOptions struct {
+		dryRun bool
+	}
+
+	// RedriveEntry a dead letter message processed by Redrive
+	RedriveEntry struct {
+		// Sequence sequence of the message in the dead letter stream
+		Sequence               uint64
+		Subject                string
+		OriginalSubject        string
+		OriginalStream         string
+		OriginalStreamSequence uint64
+		LastError              string
+		// Err reason of the message being skipped or failed to be moved
+		Err error
+	}
+
+	// RedriveResult :nodoc:
+	RedriveResult struct {
+		// Moved messages republished to their original subject, or would be republished on dry run
+		Moved []RedriveEntry
+		// Skipped messages rejected by the filter or without original subject header
+		Skipped []RedriveEntry
+		// Failed messages which failed to be republished or to be removed from the dead letter stream
+		Failed []RedriveEntry
+	}
+)
+
+// WithRedriveDryRun only lists the messages which would be moved without republishing them
+func WithRedriveDryRun() RedriveOption {
+	return func(o *redriveOptions) {
+		o.dryRun = true
+	}
+}
+
+// Redrive moves messages of the dead letter stream back to their original subject, restored from HeaderOriginalSubject.
+// A nil filter moves every message, rateLimit is the max number of messages republished per second, 0 means unlimited.
+// Moved messages are deleted from the dead letter stream. Redrive stops once every message present at the start is processed or ctx is done,
+// the messages dead lettered again while it runs are left for the next Redrive.
+// The result is returned along with an error, with the messages processed before the error.
+func Redrive(ctx context.Context, js JetStream, dlqStream string, filter RedriveFilter, rateLimit int, opts ...RedriveOption) (*RedriveResult, error) {
+	r := &redriver{
+		js:        js,
+		dlqStream: dlqStream,
+		filter:    filter,
+		result:    &RedriveResult{},
+	}
+	for _, opt := range opts {
+		opt(&r.opts)
+	}
+
+	info, err := js.StreamInfo(dlqStream)
+	if err != nil {
+		return r.result, err
+	}
+	if info.State.Msgs == 0 {
+		return r.result, nil
+	}
+	r.lastSeq = info.State.LastSeq
+
+	msgCh := make(chan *nats.Msg)
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	sub, err := js.Subscribe("", func(msg *nats.Msg) {
+		select {
+		case msgCh <- msg:
+		case <-doneCh:
+		}
+	}, nats.BindStream(dlqStream), nats.OrderedConsumer(), nats.DeliverAll())
+	if err != nil {
+		return r.result, err
+	}
+	defer func() {
+		_ = sub.Unsubscribe()
+	}()
+
+	if interval := rateLimitInterval(rateLimit); interval > 0 && !r.opts.dryRun {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		r.limiter = ticker.C
+	}
+
+	return r.run(ctx, msgCh)
+}
+
+type redriver struct {
+	js        JetStream
+	dlqStream string
+	filter    RedriveFilter
+	opts      redriveOptions
+	limiter   <-chan time.Time
+	result    *RedriveResult
+	// lastSeq the sequence of the last message of the dead letter stream at the start
+	lastSeq uint64
+}
+
+// run processes the delivered messages until the last message of the dead letter stream at the start,
+// or until no message is left when that one is deleted meanwhile
+func (r *redriver) run(ctx context.Context, msgCh <-chan *nats.Msg) (*RedriveResult, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return r.result, ctx.Err()
+		case msg := <-msgCh:
+			meta, err := msg.Metadata()
+			if err != nil || meta.Sequence.Stream > r.lastSeq {
+				return r.result, err
+			}
+
+			err = r.process(ctx, msg, meta)
+			if err != nil || meta.Sequence.Stream == r.lastSeq || meta.NumPending == 0 {
+				return r.result, err
+			}
+		}
+	}
+}
+
+// process moves or skips a single dead letter message
+func (r *redriver) process(ctx context.Context, msg *nats.Msg, meta *nats.MsgMetadata) error {
+	entry := newRedriveEntry(msg, meta)
+	switch {
+	case entry.OriginalSubject == "":
+		entry.Err = ErrMissingOriginalSubject
+		r.result.Skipped = append(r.result.Skipped, entry)
+	case r.filter != nil && !r.filter(msg):
+		r.result.Skipped = append(r.result.Skipped, entry)
+	case r.opts.dryRun:
+		r.result.Moved = append(r.result.Moved, entry)
+	default:
+		err := waitRateLimit(ctx, r.limiter)
+		if err != nil {
+			return err
+		}
+		r.result.add(moveDeadLetter(r.js, r.dlqStream, msg, entry))
+	}
+
+	return nil
+}
+
+func (r *RedriveResult) add(entry RedriveEntry) {
+	if entry.Err != nil {
+		getLogger(nil).log(LogEventRedriveFailed, entry.Err, LogFields{
+			"sequence": entry.Sequence,
+			"subject":  entry.OriginalSubject,
+		})
+		r.Failed = append(r.Failed, entry)
+		return
+	}
+	r.Moved = append(r.Moved, entry)
+}
+
+func newRedriveEntry(msg *nats.Msg, meta *nats.MsgMetadata) RedriveEntry {
+	seq, _ := strconv.ParseUint(msg.Header.Get(HeaderOriginalStreamSequence), 10, 64)
+	return RedriveEntry{
+		Sequence:               meta.Sequence.Stream,
+		Subject:                msg.Subject,
+		OriginalSubject:        msg.Header.Get(HeaderOriginalSubject),
+		OriginalStream:         msg.Header.Get(HeaderOriginalStream),
+		OriginalStreamSequence: seq,
+		LastError:              msg.Header.Get(HeaderLastError),
+	}
+}
+
+// moveDeadLetter republishes the message to its original subject, without the dead letter headers,
+// and deletes it from the dead letter stream
+func moveDeadLetter(js JetStream, dlqStream string, msg *nats.Msg, entry RedriveEntry) RedriveEntry {
+	originalMsg := nats.NewMsg(entry.OriginalSubject)
+	originalMsg.Data = msg.Data
+	for key, values := range msg.Header {
+		if strings.HasPrefix(key, "Nats-") {
+			continue
+		}
+		originalMsg.Header[key] = values
+	}
+	for _, key := range deadLetterHeaders {
+		originalMsg.Header.Del(key)
+	}
+
+	_, err := js.PublishMsg(originalMsg)
+	if err != nil {
+		entry.Err = errors.Wrap(err, "failed to republish message")
+		return entry
+	}
+
+	err = js.DeleteMsg(dlqStream, entry.Sequence)
+	if err != nil {
+		entry.Err = errors.Wrap(err, "message is republished but failed to be deleted from dead letter stream")
+	}
+	return entry
+}
+
+// rateLimitInterval the interval between messages of rateLimit per second,
+// 0 when unlimited, which includes a rate limit above a message per nanosecond
+func rateLimitInterval(rateLimit int) time.Duration {
+	if rateLimit <= 0 {
+		return 0
+	}
+	return time.Second / time.Duration(rateLimit)
+}
+
+func waitRateLimit(ctx context.Context, limiter <-chan time.Time) error {
+	if limiter == nil {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-limiter:
+		return nil
+	}
+}
@@ -0,0 +1,140 @@
+package ferstream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedrive(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	addTestStream(t, n, &nats.StreamConfig{
+		Name:     "STREAM_NAME_REDRIVE",
+		Subjects: []string{"STREAM_NAME_REDRIVE.*"},
+		Storage:  nats.MemoryStorage,
+	})
+	addTestStream(t, n, &nats.StreamConfig{
+		Name:     "DLQ_STREAM_NAME_REDRIVE",
+		Subjects: []string{"DLQ.STREAM_NAME_REDRIVE.>"},
+		Storage:  nats.MemoryStorage,
+	})
+
+	t.Run("missing dead letter stream", func(t *testing.T) {
+		result, err := Redrive(context.Background(), n, "DLQ_STREAM_NAME_REDRIVE_MISSING", nil, 0)
+		assert.Error(t, err)
+		assert.NotNil(t, result)
+	})
+
+	t.Run("empty dead letter stream", func(t *testing.T) {
+		result, err := Redrive(context.Background(), n, "DLQ_STREAM_NAME_REDRIVE", nil, 0)
+		require.NoError(t, err)
+		assert.Empty(t, result.Moved)
+		assert.Empty(t, result.Skipped)
+		assert.Empty(t, result.Failed)
+	})
+
+	msgBytes := newTestNatsEventMessage(t)
+	publishDeadLetter := func(originalSubject string) {
+		msg := nats.NewMsg("DLQ.STREAM_NAME_REDRIVE.TEST")
+		msg.Data = msgBytes
+		msg.Header.Set("Custom-Header", "custom")
+		if originalSubject != "" {
+			msg.Header.Set(HeaderOriginalSubject, originalSubject)
+			msg.Header.Set(HeaderLastError, assert.AnError.Error())
+		}
+		_, err := n.PublishMsg(msg)
+		require.NoError(t, err)
+	}
+	publishDeadLetter("STREAM_NAME_REDRIVE.A")
+	publishDeadLetter("STREAM_NAME_REDRIVE.B")
+	publishDeadLetter("")
+
+	t.Run("dry run", func(t *testing.T) {
+		result, err := Redrive(context.Background(), n, "DLQ_STREAM_NAME_REDRIVE", nil, 0, WithRedriveDryRun())
+		require.NoError(t, err)
+		require.Len(t, result.Moved, 2)
+		assert.Equal(t, "STREAM_NAME_REDRIVE.A", result.Moved[0].OriginalSubject)
+		assert.Equal(t, assert.AnError.Error(), result.Moved[0].LastError)
+		assert.Equal(t, "STREAM_NAME_REDRIVE.B", result.Moved[1].OriginalSubject)
+		require.Len(t, result.Skipped, 1)
+		assert.Equal(t, ErrMissingOriginalSubject, result.Skipped[0].Err)
+		assert.Empty(t, result.Failed)
+	})
+
+	t.Run("redrive filtered messages", func(t *testing.T) {
+		receiverCh := make(chan *nats.Msg, 1)
+		sub, err := n.Subscribe("STREAM_NAME_REDRIVE.A", func(msg *nats.Msg) {
+			receiverCh <- msg
+		}, nats.DeliverNew())
+		require.NoError(t, err)
+		defer func() { _ = sub.Unsubscribe() }()
+
+		filter := func(msg *nats.Msg) bool {
+			return msg.Header.Get(HeaderOriginalSubject) == "STREAM_NAME_REDRIVE.A"
+		}
+		result, err := Redrive(context.Background(), n, "DLQ_STREAM_NAME_REDRIVE", filter, 100)
+		require.NoError(t, err)
+		require.Len(t, result.Moved, 1)
+		assert.Len(t, result.Skipped, 2)
+		assert.Empty(t, result.Failed)
+
+		select {
+		case msg := <-receiverCh:
+			assert.Equal(t, msgBytes, msg.Data)
+			assert.Equal(t, "custom", msg.Header.Get("Custom-Header"))
+			assert.Empty(t, msg.Header.Get(HeaderOriginalSubject))
+			assert.Empty(t, msg.Header.Get(HeaderLastError))
+		case <-time.After(5 * time.Second):
+			t.Fatal("message is not redriven")
+		}
+
+		// moved message is deleted from the dead letter stream
+		result, err = Redrive(context.Background(), n, "DLQ_STREAM_NAME_REDRIVE", nil, 0, WithRedriveDryRun())
+		require.NoError(t, err)
+		assert.Len(t, result.Moved, 1)
+		assert.Len(t, result.Skipped, 1)
+	})
+
+	t.Run("stop at the last message present at the start", func(t *testing.T) {
+		// the redriven message fails again and is dead lettered again
+		sub, err := n.Subscribe("STREAM_NAME_REDRIVE.LOOP", func(msg *nats.Msg) {
+			dlqMsg := nats.NewMsg("DLQ.STREAM_NAME_REDRIVE.TEST")
+			dlqMsg.Data = msg.Data
+			dlqMsg.Header.Set(HeaderOriginalSubject, msg.Subject)
+			_, _ = n.PublishMsg(dlqMsg)
+		}, nats.DeliverNew())
+		require.NoError(t, err)
+		defer func() { _ = sub.Unsubscribe() }()
+		publishDeadLetter("STREAM_NAME_REDRIVE.LOOP")
+
+		filter := func(msg *nats.Msg) bool {
+			return msg.Header.Get(HeaderOriginalSubject) == "STREAM_NAME_REDRIVE.LOOP"
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		result, err := Redrive(ctx, n, "DLQ_STREAM_NAME_REDRIVE", filter, 10)
+		require.NoError(t, err)
+		assert.Len(t, result.Moved, 1)
+		assert.Len(t, result.Skipped, 2)
+
+		// the message dead lettered again is left for the next redrive
+		assert.Eventually(t, func() bool {
+			result, err := Redrive(ctx, n, "DLQ_STREAM_NAME_REDRIVE", filter, 0, WithRedriveDryRun())
+			return err == nil && len(result.Moved) == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestRateLimitInterval(t *testing.T) {
+	assert.Equal(t, time.Duration(0), rateLimitInterval(0))
+	assert.Equal(t, 10*time.Millisecond, rateLimitInterval(100))
+	assert.Equal(t, time.Nanosecond, rateLimitInterval(1e9))
+	assert.Equal(t, time.Duration(0), rateLimitInterval(2e9))
+}
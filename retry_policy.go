@@ -0,0 +1,128 @@
+package ferstream
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+type (
+	// RetryPolicy decides whether and when a failed message is attempted again
+	RetryPolicy interface {
+		// NextDelay returns the delay before the next attempt after the given number of failed attempts (starting from 1),
+		// ok is false when the message should not be attempted again
+		NextDelay(failedAttempts int) (delay time.Duration, ok bool)
+	}
+
+	// RetryPolicyFunc adapts a function into RetryPolicy
+	RetryPolicyFunc func(failedAttempts int) (delay time.Duration, ok bool)
+
+	exponentialRetryPolicy struct {
+		maxAttempts int
+		interval    time.Duration
+		multiplier  float64
+		maxInterval time.Duration
+		jitter      bool
+	}
+
+	fibonacciRetryPolicy struct {
+		maxAttempts int
+		unit        time.Duration
+		maxInterval time.Duration
+	}
+
+	scheduleRetryPolicy struct {
+		delays []time.Duration
+	}
+)
+
+// NextDelay :nodoc:
+func (f RetryPolicyFunc) NextDelay(failedAttempts int) (time.Duration, bool) {
+	return f(failedAttempts)
+}
+
+// NewExponentialRetryPolicy makes at most maxAttempts attempts, the delay starts from interval and is multiplied by multiplier after every attempt.
+// The delay is capped at maxInterval, 0 means no cap.
+func NewExponentialRetryPolicy(maxAttempts int, interval time.Duration, multiplier float64, maxInterval time.Duration) RetryPolicy {
+	return &exponentialRetryPolicy{
+		maxAttempts: maxAttempts,
+		interval:    interval,
+		multiplier:  multiplier,
+		maxInterval: maxInterval,
+	}
+}
+
+// NewExponentialJitterRetryPolicy same as NewExponentialRetryPolicy, but the delay is randomized between 0 and the exponential delay (full jitter),
+// so the retries of many consumers failing at the same time are spread out
+func NewExponentialJitterRetryPolicy(maxAttempts int, interval time.Duration, multiplier float64, maxInterval time.Duration) RetryPolicy {
+	return &exponentialRetryPolicy{
+		maxAttempts: maxAttempts,
+		interval:    interval,
+		multiplier:  multiplier,
+		maxInterval: maxInterval,
+		jitter:      true,
+	}
+}
+
+// NewFibonacciRetryPolicy makes at most maxAttempts attempts, the delays follow the fibonacci sequence in multiple of unit (1, 1, 2, 3, 5, ...).
+// The delay is capped at maxInterval, 0 means no cap.
+func NewFibonacciRetryPolicy(maxAttempts int, unit time.Duration, maxInterval time.Duration) RetryPolicy {
+	return &fibonacciRetryPolicy{
+		maxAttempts: maxAttempts,
+		unit:        unit,
+		maxInterval: maxInterval,
+	}
+}
+
+// NewScheduleRetryPolicy retries with the given delays in order, so it makes at most len(delays)+1 attempts
+func NewScheduleRetryPolicy(delays ...time.Duration) RetryPolicy {
+	return &scheduleRetryPolicy{delays: delays}
+}
+
+// NextDelay :nodoc:
+func (p *exponentialRetryPolicy) NextDelay(failedAttempts int) (time.Duration, bool) {
+	if failedAttempts >= p.maxAttempts {
+		return 0, false
+	}
+
+	delay := float64(p.interval) * math.Pow(p.multiplier, float64(failedAttempts-1))
+	delay = capInterval(delay, p.maxInterval)
+	if p.jitter && delay > 0 {
+		delay = rand.Float64() * delay //nolint:gosec // jitter does not need a cryptographically secure random number
+	}
+
+	return time.Duration(delay), true
+}
+
+// NextDelay :nodoc:
+func (p *fibonacciRetryPolicy) NextDelay(failedAttempts int) (time.Duration, bool) {
+	if failedAttempts >= p.maxAttempts {
+		return 0, false
+	}
+
+	prev, curr := 0.0, 1.0
+	for i := 1; i < failedAttempts; i++ {
+		prev, curr = curr, prev+curr
+	}
+
+	return time.Duration(capInterval(float64(p.unit)*curr, p.maxInterval)), true
+}
+
+// NextDelay :nodoc:
+func (p *scheduleRetryPolicy) NextDelay(failedAttempts int) (time.Duration, bool) {
+	if failedAttempts < 1 || failedAttempts > len(p.delays) {
+		return 0, false
+	}
+	return p.delays[failedAttempts-1], true
+}
+
+// capInterval caps delay at maxInterval and at the max time.Duration to prevent overflow
+func capInterval(delay float64, maxInterval time.Duration) float64 {
+	if maxInterval > 0 && delay > float64(maxInterval) {
+		return float64(maxInterval)
+	}
+	if maxDuration := math.Nextafter(math.MaxInt64, 0); delay > maxDuration {
+		return maxDuration
+	}
+	return delay
+}
@@ -0,0 +1,72 @@
+package ferstream
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertDelays(t *testing.T, policy RetryPolicy, expected []time.Duration) {
+	for i, want := range expected {
+		delay, ok := policy.NextDelay(i + 1)
+		assert.True(t, ok, "attempt %d", i+1)
+		assert.Equal(t, want, delay, "attempt %d", i+1)
+	}
+
+	_, ok := policy.NextDelay(len(expected) + 1)
+	assert.False(t, ok)
+}
+
+func TestNewExponentialRetryPolicy(t *testing.T) {
+	t.Run("exponential", func(t *testing.T) {
+		policy := NewExponentialRetryPolicy(4, time.Second, 2, 0)
+		assertDelays(t, policy, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second})
+	})
+
+	t.Run("capped at max interval", func(t *testing.T) {
+		policy := NewExponentialRetryPolicy(4, time.Second, 3, 5*time.Second)
+		assertDelays(t, policy, []time.Duration{time.Second, 3 * time.Second, 5 * time.Second})
+	})
+
+	t.Run("no overflow", func(t *testing.T) {
+		policy := NewExponentialRetryPolicy(math.MaxInt, time.Hour, 10, 0)
+		delay, ok := policy.NextDelay(100)
+		assert.True(t, ok)
+		assert.Greater(t, delay, time.Duration(0))
+	})
+}
+
+func TestNewExponentialJitterRetryPolicy(t *testing.T) {
+	policy := NewExponentialJitterRetryPolicy(4, time.Second, 2, 0)
+	for i := 1; i < 4; i++ {
+		delay, ok := policy.NextDelay(i)
+		assert.True(t, ok)
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.LessOrEqual(t, delay, time.Second<<(i-1))
+	}
+
+	_, ok := policy.NextDelay(4)
+	assert.False(t, ok)
+}
+
+func TestNewFibonacciRetryPolicy(t *testing.T) {
+	policy := NewFibonacciRetryPolicy(7, time.Second, 0)
+	assertDelays(t, policy, []time.Duration{time.Second, time.Second, 2 * time.Second, 3 * time.Second, 5 * time.Second, 8 * time.Second})
+
+	policy = NewFibonacciRetryPolicy(7, time.Second, 4*time.Second)
+	assertDelays(t, policy, []time.Duration{time.Second, time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second, 4 * time.Second})
+}
+
+func TestNewScheduleRetryPolicy(t *testing.T) {
+	policy := NewScheduleRetryPolicy(time.Second, time.Minute, time.Hour)
+	assertDelays(t, policy, []time.Duration{time.Second, time.Minute, time.Hour})
+}
+
+func TestRetryPolicyFunc(t *testing.T) {
+	policy := RetryPolicyFunc(func(failedAttempts int) (time.Duration, bool) {
+		return time.Duration(failedAttempts) * time.Second, failedAttempts < 3
+	})
+	assertDelays(t, policy, []time.Duration{time.Second, 2 * time.Second})
+}
@@ -0,0 +1,148 @@
+package ferstream
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// shutdownPollInterval how often Shutdown checks whether the subscriptions and the connection are done
+const shutdownPollInterval = 10 * time.Millisecond
+
+// shutdowner implemented by the JetStream returned by NewNATSConnection
+type shutdowner interface {
+	shutdown(ctx context.Context) error
+}
+
+// Shutdown drains every subscription created by js, and every JetStreamV2 Consume, waits until their running message handlers return,
+// waits for the acks of the pending async publishes, then drains and closes the connection.
+// It stops waiting once ctx is done, closes the connection and returns a *ShutdownError
+// with the subscriptions which did not finish. The context of a running NewMessageHandler
+// is cancelled once its subscription is drained, so the handler stops retrying and the message is redelivered.
+// The messages queued in a WorkerPool count as running handlers, they are nak-ed by the handler once its context is cancelled.
+// Like SafeClose, the consumers created by Subscribe are deleted by nats.go once drained,
+// bind to a consumer created by EnsureConsumer to keep it.
+func Shutdown(ctx context.Context, js JetStream) error {
+	if js == nil {
+		return nil
+	}
+	if s, ok := js.(shutdowner); ok {
+		return s.shutdown(ctx)
+	}
+	SafeClose(js)
+	return nil
+}
+
+func (j *jsImpl) shutdown(ctx context.Context) error {
+	if j.natsConn == nil || j.natsConn.IsClosed() {
+		return nil
+	}
+	defer j.natsConn.Close()
+
+	subs := j.subs.all()
+	for _, tracked := range subs {
+		// a closed subscription can not be drained, it is done once its handlers return
+		_ = tracked.sub.Drain()
+	}
+	consumes := j.subs.allConsumes()
+	for _, consume := range consumes {
+		consume.drain()
+	}
+
+	shutdownErr := &ShutdownError{}
+	waitUntil(ctx, func() bool {
+		return len(runningNames(subs, consumes)) == 0
+	})
+	shutdownErr.Subscriptions = runningNames(subs, consumes)
+
+	if j.jsCtx != nil && j.jsCtx.PublishAsyncPending() > 0 {
+		_ = j.PublishAsyncComplete(ctx)
+		shutdownErr.PendingPublishes = j.jsCtx.PublishAsyncPending()
+	}
+
+	// drain the subscriptions not created by js and flush the publishes
+	if j.natsConn.Drain() == nil {
+		waitUntil(ctx, j.natsConn.IsClosed)
+	}
+
+	if len(shutdownErr.Subscriptions) == 0 && shutdownErr.PendingPublishes == 0 {
+		return nil
+	}
+	shutdownErr.Err = ctx.Err()
+	return shutdownErr
+}
+
+// runningNames returns the names of the subscriptions and the consumes which are still running
+func runningNames(subs []*trackedSubscription, consumes []*trackedConsume) []string {
+	var names []string
+	for _, tracked := range subs {
+		if tracked.running() {
+			names = append(names, tracked.name())
+		}
+	}
+	for _, consume := range consumes {
+		if consume.running() {
+			names = append(names, consume.name())
+		}
+	}
+	return names
+}
+
+// waitUntil polls done until it returns true or ctx is done
+func waitUntil(ctx context.Context, done func() bool) {
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+	for !done() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// running the subscription still has pending messages or running message handlers
+func (t *trackedSubscription) running() bool {
+	return t.sub.IsValid() || t.handlers.Load() > 0
+}
+
+// name the subject of the subscription, with its queue if any
+func (t *trackedSubscription) name() string {
+	if t.key.queue == "" {
+		return t.key.subject
+	}
+	return t.key.subject + " (" + t.key.queue + ")"
+}
+
+// drain stops the consume once its buffered messages are handled, the running message contexts are cancelled
+func (c *trackedConsume) drain() {
+	c.cancel()
+	c.cc.Drain()
+}
+
+// running the consume still has buffered messages or running message handlers
+func (c *trackedConsume) running() bool {
+	select {
+	case <-c.cc.Closed():
+		return c.handlers.Load() > 0
+	default:
+		return true
+	}
+}
+
+// name the stream and the name of the consumer
+func (c *trackedConsume) name() string {
+	return c.stream + "." + c.consumer
+}
+
+// startHandling counts a running message handler of the subscription or the consume of msg, if it is tracked by a JetStream,
+// the returned func is called once the handler returns
+func startHandling(msg *nats.Msg) (done func()) {
+	stats, ok := getHandlerStats(msg)
+	if !ok {
+		return func() {}
+	}
+	stats.handlers.Add(1)
+	return func() { stats.handlers.Add(-1) }
+}
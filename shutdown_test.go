@@ -0,0 +1,131 @@
+package ferstream
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdown(t *testing.T) {
+	subscribe := func(t *testing.T, durable string, msgHandler ContextMessageHandler) (JetStream, string) {
+		n, err := NewNATSConnection(defaultURL, nil)
+		require.NoError(t, err)
+
+		_, err = n.AddStream(&nats.StreamConfig{
+			Name:     "STREAM_NAME_SHUTDOWN",
+			Subjects: []string{"STREAM_NAME_SHUTDOWN.*"},
+			Storage:  nats.FileStorage,
+		})
+		require.NoError(t, err)
+
+		subject := "STREAM_NAME_SHUTDOWN." + durable
+		_, err = n.Subscribe(subject, NewMessageHandler(NewNatsEventMessage(), msgHandler),
+			nats.Durable(durable), nats.ManualAck(), nats.DeliverNew())
+		require.NoError(t, err)
+
+		data, err := NewNatsEventMessage().WithEvent(&NatsEvent{ID: 1, UserID: 2}).Build()
+		require.NoError(t, err)
+		_, err = n.Publish(subject, data)
+		require.NoError(t, err)
+		return n, subject
+	}
+
+	t.Run("wait for running handlers", func(t *testing.T) {
+		started := make(chan struct{})
+		var finished atomic.Bool
+		n, _ := subscribe(t, "FINISHED", func(_ context.Context, _ MessageParser) error {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			finished.Store(true)
+			return nil
+		})
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		require.NoError(t, Shutdown(ctx, n))
+		assert.True(t, finished.Load())
+		assert.True(t, n.GetNATSConnection().IsClosed())
+	})
+
+	t.Run("wait for messages queued in a worker pool", func(t *testing.T) {
+		n, err := NewNATSConnection(defaultURL, nil)
+		require.NoError(t, err)
+
+		started := make(chan struct{})
+		var handled atomic.Int32
+		msgHandler := NewMessageHandler(NewNatsEventMessage(), func(ctx context.Context, _ MessageParser) error {
+			if handled.Load() == 0 {
+				close(started)
+				time.Sleep(100 * time.Millisecond)
+			}
+			return nil
+		})
+		pool := NewWorkerPool(1, func(msg *nats.Msg) {
+			msgHandler(msg)
+			handled.Add(1)
+		}, WithQueueSize(3))
+		defer func() { _ = pool.Shutdown(context.Background()) }()
+
+		subject := "STREAM_NAME_SHUTDOWN.POOL"
+		_, err = n.Subscribe(subject, pool.Handle, nats.Durable("POOL"), nats.ManualAck(), nats.DeliverNew())
+		require.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			_, err = n.Publish(subject, newTestNatsEventMessage(t))
+			require.NoError(t, err)
+		}
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		require.NoError(t, Shutdown(ctx, n))
+		assert.Equal(t, int32(3), handled.Load())
+	})
+
+	t.Run("report unfinished subscriptions", func(t *testing.T) {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+		n, subject := subscribe(t, "UNFINISHED", func(_ context.Context, _ MessageParser) error {
+			close(started)
+			<-release
+			return nil
+		})
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		err := Shutdown(ctx, n)
+		assert.ErrorIs(t, err, ErrShutdownIncomplete)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		var shutdownErr *ShutdownError
+		require.ErrorAs(t, err, &shutdownErr)
+		assert.Equal(t, []string{subject}, shutdownErr.Subscriptions)
+		assert.True(t, n.GetNATSConnection().IsClosed())
+	})
+
+	t.Run("closed connection", func(t *testing.T) {
+		n, err := NewNATSConnection(defaultURL, nil)
+		require.NoError(t, err)
+		n.GetNATSConnection().Close()
+
+		assert.NoError(t, Shutdown(context.Background(), n))
+		assert.NoError(t, Shutdown(context.Background(), nil))
+	})
+}
+
+func TestShutdownError_Error(t *testing.T) {
+	err := &ShutdownError{
+		Subscriptions:    []string{"SUBJECT_A", "SUBJECT_B (queue)"},
+		PendingPublishes: 2,
+		Err:              context.DeadlineExceeded,
+	}
+	assert.Equal(t, "ferstreamErr: shutdown incomplete: subscriptions SUBJECT_A, SUBJECT_B (queue) did not finish; "+
+		"2 async publishes are not acked; context deadline exceeded", err.Error())
+}
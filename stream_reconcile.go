@@ -0,0 +1,255 @@
+package ferstream
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// defaultDuplicatesWindow the server's default duplicate window
+const defaultDuplicatesWindow = 2 * time.Minute
+
+type (
+	// ReconcileStreamOptions options of ReconcileStream
+	ReconcileStreamOptions struct {
+		// PlanOnly computes the changes without applying them
+		PlanOnly bool
+		// AllowDestructive applies changes which delete stored messages or reject messages the stream accepted before,
+		// e.g. shrinking MaxAge or removing subjects
+		AllowDestructive bool
+	}
+
+	// StreamConfigChange a field of the stream config which differs from the live config
+	StreamConfigChange struct {
+		Field       string
+		From        any
+		To          any
+		Destructive bool
+	}
+
+	// StreamReconcileResult the result of ReconcileStream
+	StreamReconcileResult struct {
+		// Created is true when the stream does not exist, and it is created unless PlanOnly
+		Created bool
+		// Changes the fields which differ from the live config
+		Changes []StreamConfigChange
+		// Applied is true when the stream is created or updated
+		Applied bool
+		// Info the stream info after reconciling, it is nil when the stream is not created yet
+		Info *nats.StreamInfo
+	}
+
+	// streamField compares a field of the live stream config with the desired one
+	streamField struct {
+		name  string
+		value func(cfg *nats.StreamConfig) any
+		// destructive returns true when the change deletes stored messages or rejects messages the stream accepted before
+		destructive func(live, desired *nats.StreamConfig) bool
+		// immutable returns true when the server refuses the change
+		immutable func(live, desired *nats.StreamConfig) bool
+	}
+)
+
+var streamFields = []streamField{
+	{name: "Description", value: func(c *nats.StreamConfig) any { return c.Description }},
+	{name: "Subjects", value: func(c *nats.StreamConfig) any { return c.Subjects }, destructive: removesSubjects},
+	{
+		name:        "Retention",
+		value:       func(c *nats.StreamConfig) any { return c.Retention },
+		destructive: func(_, d *nats.StreamConfig) bool { return d.Retention != nats.LimitsPolicy },
+		immutable: func(l, d *nats.StreamConfig) bool {
+			return l.Retention == nats.WorkQueuePolicy || d.Retention == nats.WorkQueuePolicy
+		},
+	},
+	{name: "MaxConsumers", value: func(c *nats.StreamConfig) any { return c.MaxConsumers }, immutable: always},
+	{name: "MaxMsgs", value: func(c *nats.StreamConfig) any { return c.MaxMsgs }, destructive: func(l, d *nats.StreamConfig) bool {
+		return shrinksLimit(l.MaxMsgs, d.MaxMsgs)
+	}},
+	{name: "MaxBytes", value: func(c *nats.StreamConfig) any { return c.MaxBytes }, destructive: func(l, d *nats.StreamConfig) bool {
+		return shrinksLimit(l.MaxBytes, d.MaxBytes)
+	}},
+	{name: "Discard", value: func(c *nats.StreamConfig) any { return c.Discard }},
+	{name: "DiscardNewPerSubject", value: func(c *nats.StreamConfig) any { return c.DiscardNewPerSubject }},
+	{name: "MaxAge", value: func(c *nats.StreamConfig) any { return c.MaxAge }, destructive: func(l, d *nats.StreamConfig) bool {
+		return d.MaxAge > 0 && (l.MaxAge == 0 || d.MaxAge < l.MaxAge)
+	}},
+	{name: "MaxMsgsPerSubject", value: func(c *nats.StreamConfig) any { return c.MaxMsgsPerSubject }, destructive: func(l, d *nats.StreamConfig) bool {
+		return shrinksLimit(l.MaxMsgsPerSubject, d.MaxMsgsPerSubject)
+	}},
+	{name: "MaxMsgSize", value: func(c *nats.StreamConfig) any { return c.MaxMsgSize }, destructive: func(l, d *nats.StreamConfig) bool {
+		return shrinksLimit(int64(l.MaxMsgSize), int64(d.MaxMsgSize))
+	}},
+	{name: "Storage", value: func(c *nats.StreamConfig) any { return c.Storage }, immutable: always},
+	{name: "Replicas", value: func(c *nats.StreamConfig) any { return c.Replicas }},
+	{name: "NoAck", value: func(c *nats.StreamConfig) any { return c.NoAck }},
+	{name: "Duplicates", value: func(c *nats.StreamConfig) any { return c.Duplicates }},
+	{name: "Placement", value: func(c *nats.StreamConfig) any { return c.Placement }},
+	{name: "Mirror", value: func(c *nats.StreamConfig) any { return c.Mirror }, immutable: always},
+	{name: "Sources", value: func(c *nats.StreamConfig) any { return c.Sources }},
+	{name: "Sealed", value: func(c *nats.StreamConfig) any { return c.Sealed }, immutable: func(l, _ *nats.StreamConfig) bool { return l.Sealed }},
+	{name: "DenyDelete", value: func(c *nats.StreamConfig) any { return c.DenyDelete }, immutable: func(l, _ *nats.StreamConfig) bool { return l.DenyDelete }},
+	{name: "DenyPurge", value: func(c *nats.StreamConfig) any { return c.DenyPurge }, immutable: func(l, _ *nats.StreamConfig) bool { return l.DenyPurge }},
+	{name: "AllowRollup", value: func(c *nats.StreamConfig) any { return c.AllowRollup }},
+	{name: "Compression", value: func(c *nats.StreamConfig) any { return c.Compression }},
+	{name: "SubjectTransform", value: func(c *nats.StreamConfig) any { return c.SubjectTransform }},
+	{name: "RePublish", value: func(c *nats.StreamConfig) any { return c.RePublish }},
+	{name: "AllowDirect", value: func(c *nats.StreamConfig) any { return c.AllowDirect }},
+	{name: "MirrorDirect", value: func(c *nats.StreamConfig) any { return c.MirrorDirect }},
+	{name: "ConsumerLimits", value: func(c *nats.StreamConfig) any { return c.ConsumerLimits }},
+	{name: "Metadata", value: func(c *nats.StreamConfig) any { return userMetadata(c.Metadata) }},
+	{name: "AllowMsgTTL", value: func(c *nats.StreamConfig) any { return c.AllowMsgTTL }, immutable: func(l, _ *nats.StreamConfig) bool { return l.AllowMsgTTL }},
+	{name: "SubjectDeleteMarkerTTL", value: func(c *nats.StreamConfig) any { return c.SubjectDeleteMarkerTTL }},
+}
+
+// ReconcileStream creates the stream, or updates it to the desired config.
+// The field-level changes are logged and returned, PlanOnly returns them without applying.
+// An ImmutableFieldError is returned when a field which can not be updated is changed, e.g. Storage,
+// and a DestructiveChangeError is returned for a destructive change unless AllowDestructive is set.
+// The result holds the changes even when one of those errors is returned.
+func ReconcileStream(js JetStream, cfg *nats.StreamConfig, opts ReconcileStreamOptions) (*StreamReconcileResult, error) {
+	info, err := js.StreamInfo(cfg.Name)
+	switch {
+	case errors.Is(err, nats.ErrStreamNotFound):
+		return createStream(js, cfg, opts)
+	case err != nil:
+		return nil, err
+	}
+
+	result := &StreamReconcileResult{Info: info}
+	result.Changes, err = diffStreamConfig(&info.Config, cfg, opts)
+	if err != nil || opts.PlanOnly || len(result.Changes) == 0 {
+		return result, err
+	}
+
+	logStreamChanges(cfg.Name, result.Changes)
+	result.Info, err = js.UpdateStream(cfg)
+	if err != nil {
+		return result, err
+	}
+	result.Applied = true
+	return result, nil
+}
+
+func createStream(js JetStream, cfg *nats.StreamConfig, opts ReconcileStreamOptions) (*StreamReconcileResult, error) {
+	result := &StreamReconcileResult{Created: true}
+	if opts.PlanOnly {
+		return result, nil
+	}
+
+	getLogger(nil).log(LogEventStream, "creating stream", LogFields{"stream": cfg.Name})
+	info, err := js.AddStream(cfg)
+	if err != nil {
+		return result, err
+	}
+	result.Info = info
+	result.Applied = true
+	return result, nil
+}
+
+// diffStreamConfig returns the changes from live to desired,
+// the desired config is compared with the server's defaults applied, so an unset limit is not a change
+func diffStreamConfig(live, desired *nats.StreamConfig, opts ReconcileStreamOptions) ([]StreamConfigChange, error) {
+	normalized := withStreamDefaults(desired)
+
+	var changes []StreamConfigChange
+	var immutableFields, destructiveFields []string
+	for _, field := range streamFields {
+		from, to := field.value(live), field.value(normalized)
+		if reflect.DeepEqual(from, to) {
+			continue
+		}
+
+		change := StreamConfigChange{Field: field.name, From: from, To: to}
+		if field.immutable != nil && field.immutable(live, normalized) {
+			immutableFields = append(immutableFields, field.name)
+		}
+		if field.destructive != nil && field.destructive(live, normalized) {
+			change.Destructive = true
+			destructiveFields = append(destructiveFields, field.name)
+		}
+		changes = append(changes, change)
+	}
+
+	switch {
+	case len(immutableFields) > 0:
+		return changes, &ImmutableFieldError{Name: "stream " + desired.Name, Fields: immutableFields}
+	case len(destructiveFields) > 0 && !opts.AllowDestructive:
+		return changes, &DestructiveChangeError{Name: "stream " + desired.Name, Fields: destructiveFields}
+	}
+	return changes, nil
+}
+
+// withStreamDefaults returns a copy of cfg with the defaults the server applies on create and update
+func withStreamDefaults(cfg *nats.StreamConfig) *nats.StreamConfig {
+	c := *cfg
+	for _, limit := range []*int64{&c.MaxMsgs, &c.MaxBytes, &c.MaxMsgsPerSubject} {
+		if *limit == 0 {
+			*limit = -1
+		}
+	}
+	if c.MaxMsgSize == 0 {
+		c.MaxMsgSize = -1
+	}
+	if c.MaxConsumers == 0 {
+		c.MaxConsumers = -1
+	}
+	if c.Replicas == 0 {
+		c.Replicas = 1
+	}
+	if c.Duplicates == 0 && c.Mirror == nil {
+		c.Duplicates = defaultDuplicatesWindow
+		if c.MaxAge != 0 && c.MaxAge < defaultDuplicatesWindow {
+			c.Duplicates = c.MaxAge
+		}
+	}
+	if len(c.Subjects) == 0 && c.Mirror == nil && len(c.Sources) == 0 {
+		c.Subjects = []string{c.Name}
+	}
+	return &c
+}
+
+func logStreamChanges(name string, changes []StreamConfigChange) {
+	for _, change := range changes {
+		getLogger(nil).log(LogEventStream, "updating stream config", LogFields{
+			"stream":      name,
+			"field":       change.Field,
+			"from":        fmt.Sprintf("%v", change.From),
+			"to":          fmt.Sprintf("%v", change.To),
+			"destructive": change.Destructive,
+		})
+	}
+}
+
+func always(_, _ *nats.StreamConfig) bool {
+	return true
+}
+
+func removesSubjects(live, desired *nats.StreamConfig) bool {
+	for _, subject := range live.Subjects {
+		if !slices.Contains(desired.Subjects, subject) {
+			return true
+		}
+	}
+	return false
+}
+
+// shrinksLimit returns true when the desired limit is lower than the live one, -1 is unlimited
+func shrinksLimit(live, desired int64) bool {
+	return desired >= 0 && (live < 0 || desired < live)
+}
+
+// userMetadata returns the metadata without the entries added by the server
+func userMetadata(metadata map[string]string) map[string]string {
+	userEntries := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		if !strings.HasPrefix(key, "_nats.") {
+			userEntries[key] = value
+		}
+	}
+	return userEntries
+}
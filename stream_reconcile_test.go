@@ -0,0 +1,135 @@
+package ferstream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileStream(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	cfg := nats.StreamConfig{
+		Name:     "STREAM_NAME_RECONCILE",
+		Subjects: []string{"STREAM_NAME_RECONCILE.A", "STREAM_NAME_RECONCILE.B"},
+		MaxAge:   time.Hour,
+		Storage:  nats.MemoryStorage,
+	}
+	// plan create expects no stream left by a previous run
+	_ = n.DeleteStream(cfg.Name)
+
+	t.Run("plan create", func(t *testing.T) {
+		result, err := ReconcileStream(n, &cfg, ReconcileStreamOptions{PlanOnly: true})
+		require.NoError(t, err)
+		assert.True(t, result.Created)
+		assert.False(t, result.Applied)
+		assert.Nil(t, result.Info)
+
+		_, err = n.StreamInfo(cfg.Name)
+		assert.ErrorIs(t, err, nats.ErrStreamNotFound)
+	})
+
+	t.Run("create", func(t *testing.T) {
+		result, err := ReconcileStream(n, &cfg, ReconcileStreamOptions{})
+		require.NoError(t, err)
+		assert.True(t, result.Created)
+		assert.True(t, result.Applied)
+		assert.Equal(t, cfg.Subjects, result.Info.Config.Subjects)
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		result, err := ReconcileStream(n, &cfg, ReconcileStreamOptions{})
+		require.NoError(t, err)
+		assert.False(t, result.Created)
+		assert.False(t, result.Applied)
+		assert.Empty(t, result.Changes)
+	})
+
+	t.Run("plan update", func(t *testing.T) {
+		updateCfg := cfg
+		updateCfg.Description = "reconciled"
+		updateCfg.MaxAge = 2 * time.Hour
+
+		result, err := ReconcileStream(n, &updateCfg, ReconcileStreamOptions{PlanOnly: true})
+		require.NoError(t, err)
+		assert.False(t, result.Applied)
+		assert.Equal(t, []StreamConfigChange{
+			{Field: "Description", From: "", To: "reconciled"},
+			{Field: "MaxAge", From: time.Hour, To: 2 * time.Hour},
+		}, result.Changes)
+
+		info, err := n.StreamInfo(cfg.Name)
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour, info.Config.MaxAge)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		updateCfg := cfg
+		updateCfg.Subjects = append([]string{"STREAM_NAME_RECONCILE.C"}, cfg.Subjects...)
+		updateCfg.MaxAge = 2 * time.Hour
+
+		result, err := ReconcileStream(n, &updateCfg, ReconcileStreamOptions{})
+		require.NoError(t, err)
+		assert.True(t, result.Applied)
+		assert.Len(t, result.Changes, 2)
+		assert.Equal(t, 2*time.Hour, result.Info.Config.MaxAge)
+		cfg = updateCfg
+	})
+
+	t.Run("refuse destructive", func(t *testing.T) {
+		updateCfg := cfg
+		updateCfg.Subjects = []string{"STREAM_NAME_RECONCILE.A"}
+		updateCfg.MaxAge = time.Hour
+
+		result, err := ReconcileStream(n, &updateCfg, ReconcileStreamOptions{})
+		assert.ErrorIs(t, err, ErrDestructiveChange)
+		var destructiveErr *DestructiveChangeError
+		require.ErrorAs(t, err, &destructiveErr)
+		assert.Equal(t, []string{"Subjects", "MaxAge"}, destructiveErr.Fields)
+		assert.False(t, result.Applied)
+		for _, change := range result.Changes {
+			assert.True(t, change.Destructive)
+		}
+
+		result, err = ReconcileStream(n, &updateCfg, ReconcileStreamOptions{AllowDestructive: true})
+		require.NoError(t, err)
+		assert.True(t, result.Applied)
+		assert.Equal(t, updateCfg.Subjects, result.Info.Config.Subjects)
+		assert.Equal(t, time.Hour, result.Info.Config.MaxAge)
+		cfg = updateCfg
+	})
+
+	t.Run("immutable", func(t *testing.T) {
+		updateCfg := cfg
+		updateCfg.Storage = nats.FileStorage
+		updateCfg.Retention = nats.WorkQueuePolicy
+
+		result, err := ReconcileStream(n, &updateCfg, ReconcileStreamOptions{AllowDestructive: true})
+		assert.ErrorIs(t, err, ErrImmutableField)
+		var immutableErr *ImmutableFieldError
+		require.ErrorAs(t, err, &immutableErr)
+		assert.Equal(t, []string{"Retention", "Storage"}, immutableErr.Fields)
+		assert.False(t, result.Applied)
+
+		// AddStream fails with the same error instead of the server's
+		_, err = n.AddStream(&updateCfg)
+		assert.ErrorIs(t, err, ErrImmutableField)
+	})
+}
+
+func TestWithStreamDefaults(t *testing.T) {
+	cfg := withStreamDefaults(&nats.StreamConfig{Name: "STREAM", MaxAge: time.Minute})
+	assert.Equal(t, int64(-1), cfg.MaxMsgs)
+	assert.Equal(t, int64(-1), cfg.MaxBytes)
+	assert.Equal(t, int64(-1), cfg.MaxMsgsPerSubject)
+	assert.Equal(t, int32(-1), cfg.MaxMsgSize)
+	assert.Equal(t, -1, cfg.MaxConsumers)
+	assert.Equal(t, 1, cfg.Replicas)
+	assert.Equal(t, time.Minute, cfg.Duplicates)
+	assert.Equal(t, []string{"STREAM"}, cfg.Subjects)
+}
@@ -0,0 +1,296 @@
+package ferstream
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+type (
+	// SubscriptionLister implemented by the JetStream returned by NewNATSConnection
+	SubscriptionLister interface {
+		Subscriptions() []SubscriptionInfo
+	}
+
+	// SubscriptionInfo a subscription created by Subscribe, QueueSubscribe or PullSubscribe,
+	// Durable is empty for an ephemeral consumer
+	SubscriptionInfo struct {
+		Subject      string
+		Queue        string
+		Durable      string
+		Subscription *nats.Subscription
+	}
+
+	// subscriptionKey identifies a subscription, an empty durable of a lookup matches any durable
+	subscriptionKey struct {
+		subject string
+		queue   string
+		durable string
+	}
+
+	// subscriptionRegistry the subscriptions created by a JetStream, and the consumes of a JetStreamV2
+	subscriptionRegistry struct {
+		mu       sync.Mutex
+		subs     []*trackedSubscription
+		consumes []*trackedConsume
+		// generation is incremented every time the clients are initialized again on reconnect
+		generation int
+		// reinitializing the clients are initialized again on reconnect, only then subscriptions are reused
+		reinitializing bool
+	}
+
+	trackedSubscription struct {
+		handlerStats
+		consumerLimits
+		sub *nats.Subscription
+		key subscriptionKey
+		// generation the registry generation in which the subscription is last returned by a subscribe
+		generation int
+	}
+
+	// consumerLimits the consumer config the message handlers depend on, resolved once when subscribing
+	consumerLimits struct {
+		ackWait time.Duration
+		// maxDeliver the deliveries of a message after which the server stops redelivering it, 0 when unlimited
+		maxDeliver int
+	}
+
+	// handlerStats the message handlers of a subscription or a consume
+	handlerStats struct {
+		lastSuccess atomic.Int64
+		// handlers the number of running message handlers
+		handlers atomic.Int64
+	}
+)
+
+// trackedSubscriptions the tracked subscription of every *nats.Subscription created by a JetStream,
+// message handlers do not know their JetStream and find it by the subscription of the message
+var trackedSubscriptions sync.Map
+
+func newSubscriptionRegistry() *subscriptionRegistry {
+	return &subscriptionRegistry{}
+}
+
+// Subscriptions returns the open subscriptions created by the connection, in the order they are created
+func (j *jsImpl) Subscriptions() []SubscriptionInfo {
+	subs := j.subs.all()
+	infos := make([]SubscriptionInfo, 0, len(subs))
+	for _, tracked := range subs {
+		infos = append(infos, SubscriptionInfo{
+			Subject:      tracked.key.subject,
+			Queue:        tracked.key.queue,
+			Durable:      tracked.key.durable,
+			Subscription: tracked.sub,
+		})
+	}
+	return infos
+}
+
+// subscribe returns the subscription of key created before the reconnect while the clients are initialized again,
+// nats.go resubscribes it on reconnect so creating it again would duplicate it, otherwise the subscription is created with cb and tracked.
+// The callback waits until the subscription is tracked, so the message handlers find the limits of its consumer.
+func (j *jsImpl) subscribe(key subscriptionKey, cb nats.MsgHandler, create func(cb nats.MsgHandler) (*nats.Subscription, error)) (*nats.Subscription, error) {
+	if !j.isValidConn() {
+		return nil, ErrConnectionLost
+	}
+	if sub := j.subs.resubscribe(key); sub != nil {
+		return sub, nil
+	}
+
+	tracked := make(chan struct{})
+	if cb != nil {
+		handle := cb
+		cb = func(msg *nats.Msg) {
+			<-tracked
+			handle(msg)
+		}
+	}
+
+	sub, err := create(cb)
+	if err != nil {
+		return nil, err
+	}
+	defer close(tracked)
+
+	durable, limits := subscriptionConsumer(sub)
+	if key.durable == "" {
+		key.durable = durable
+	}
+	j.subs.track(sub, key, limits)
+	return sub, nil
+}
+
+// subscriptionConsumer returns the durable name and the limits of the consumer of sub, the durable of Subscribe is one of its options
+func subscriptionConsumer(sub *nats.Subscription) (string, consumerLimits) {
+	info, err := sub.ConsumerInfo()
+	if err != nil {
+		return "", newConsumerLimits(0, 0)
+	}
+	return info.Config.Durable, newConsumerLimits(info.Config.AckWait, info.Config.MaxDeliver)
+}
+
+// newConsumerLimits returns the limits of a consumer, with the server defaults for the unset ones
+func newConsumerLimits(ackWait time.Duration, maxDeliver int) consumerLimits {
+	if ackWait <= 0 {
+		ackWait = defaultAckWait
+	}
+	return consumerLimits{ackWait: ackWait, maxDeliver: max(maxDeliver, 0)}
+}
+
+// delivered returns true when the server does not redeliver a message delivered numDelivered times
+func (l consumerLimits) delivered(numDelivered uint64) bool {
+	return l.maxDeliver > 0 && numDelivered >= uint64(l.maxDeliver)
+}
+
+// reinitialize starts a new generation and runs init, the subscriptions created before can be returned once more
+// by resubscribe until init returns. init initializes the clients again on reconnect.
+func (s *subscriptionRegistry) reinitialize(init func() error) error {
+	s.mu.Lock()
+	s.generation++
+	s.reinitializing = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.reinitializing = false
+	}()
+	return init()
+}
+
+// resubscribe returns the first open subscription of key which is not yet returned since the clients are
+// initialized again, or nil when they are not being initialized. The clients subscribe in the same order,
+// so subscriptions with the same subject and queue but a durable unknown before subscribing are matched in order.
+func (s *subscriptionRegistry) resubscribe(key subscriptionKey) *nats.Subscription {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.reinitializing {
+		return nil
+	}
+	i := slices.IndexFunc(s.subs, func(t *trackedSubscription) bool {
+		return t.generation < s.generation && t.key.matches(key) && t.sub.IsValid()
+	})
+	if i < 0 {
+		return nil
+	}
+	s.subs[i].generation = s.generation
+	return s.subs[i].sub
+}
+
+// track adds the subscription of key to the registry, it is removed once closed
+func (s *subscriptionRegistry) track(sub *nats.Subscription, key subscriptionKey, limits consumerLimits) {
+	if s == nil || sub == nil {
+		return
+	}
+
+	s.mu.Lock()
+	tracked := &trackedSubscription{consumerLimits: limits, sub: sub, key: key, generation: s.generation}
+	s.subs = append(s.subs, tracked)
+	s.mu.Unlock()
+	trackedSubscriptions.Store(sub, tracked)
+
+	closed := sub.StatusChanged(nats.SubscriptionClosed)
+	go func() {
+		<-closed
+		s.untrack(tracked)
+	}()
+}
+
+func (s *subscriptionRegistry) untrack(tracked *trackedSubscription) {
+	trackedSubscriptions.Delete(tracked.sub)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs = slices.DeleteFunc(s.subs, func(t *trackedSubscription) bool {
+		return t == tracked
+	})
+}
+
+// trackConsume adds the consume to the registry, it is removed once closed
+func (s *subscriptionRegistry) trackConsume(consume *trackedConsume) {
+	s.mu.Lock()
+	s.consumes = append(s.consumes, consume)
+	s.mu.Unlock()
+
+	go func() {
+		<-consume.cc.Closed()
+		consume.cancel()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.consumes = slices.DeleteFunc(s.consumes, func(c *trackedConsume) bool {
+			return c == consume
+		})
+	}()
+}
+
+// allConsumes returns a copy of the tracked consumes
+func (s *subscriptionRegistry) allConsumes() []*trackedConsume {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.consumes)
+}
+
+// all returns a copy of the tracked subscriptions
+func (s *subscriptionRegistry) all() []*trackedSubscription {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.subs)
+}
+
+// matches the subject and queue are equal, and the durable when the durable of other is known
+func (k subscriptionKey) matches(other subscriptionKey) bool {
+	return k.subject == other.subject && k.queue == other.queue && (other.durable == "" || k.durable == other.durable)
+}
+
+// getHandlerStats returns the handler stats of the subscription or the consume which delivered msg,
+// if it is tracked by a JetStream
+func getHandlerStats(msg *nats.Msg) (*handlerStats, bool) {
+	if consumed, ok := getConsumedMsg(msg); ok {
+		return &consumed.consume.handlerStats, true
+	}
+	if msg.Sub == nil {
+		return nil, false
+	}
+	if tracked, ok := trackedSubscriptions.Load(msg.Sub); ok {
+		return &tracked.(*trackedSubscription).handlerStats, true
+	}
+	return nil, false
+}
+
+// getConsumerLimits returns the consumer limits of the subscription or the consume which delivered msg,
+// the server defaults when it is not tracked by a JetStream
+func getConsumerLimits(msg *nats.Msg) consumerLimits {
+	if consumed, ok := getConsumedMsg(msg); ok {
+		return consumed.consume.consumerLimits
+	}
+	if msg.Sub == nil {
+		return newConsumerLimits(0, 0)
+	}
+	if tracked, ok := trackedSubscriptions.Load(msg.Sub); ok {
+		return tracked.(*trackedSubscription).consumerLimits
+	}
+	return newConsumerLimits(0, 0)
+}
+
+// markSucceeded records the last successful message of the subscription or the consume of msg
+func markSucceeded(msg *nats.Msg) {
+	if stats, ok := getHandlerStats(msg); ok {
+		stats.lastSuccess.Store(time.Now().UnixNano())
+	}
+}
@@ -0,0 +1,149 @@
+package ferstream
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resubscribingClient subscribes again once the server lost its stream
+type resubscribingClient struct {
+	mu   sync.Mutex
+	js   JetStream
+	subs []*nats.Subscription
+	errs []error
+}
+
+func (c *resubscribingClient) RegisterNATSJetStream(js JetStream) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.js = js
+}
+
+func (c *resubscribingClient) InitStream() error {
+	for _, name := range []string{"STREAM_NAME_REGISTRY", "STREAM_NAME_REGISTRY_LOST"} {
+		_, err := c.js.AddStream(&nats.StreamConfig{
+			Name:     name,
+			Subjects: []string{name + ".*"},
+			Storage:  nats.MemoryStorage,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *resubscribingClient) SubscribeJetStreamEvent() error {
+	sub, err := c.js.QueueSubscribe("STREAM_NAME_REGISTRY.TEST", "registry", func(msg *nats.Msg) {
+		_ = msg.Ack()
+	}, nats.Durable("registry"), nats.ManualAck(), nats.DeliverNew())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subs = append(c.subs, sub)
+	c.errs = append(c.errs, err)
+	return err
+}
+
+func (c *resubscribingClient) registrations() ([]*nats.Subscription, []error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.subs, c.errs
+}
+
+func TestSubscriptions(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	addTestStream(t, n, &nats.StreamConfig{
+		Name:     "STREAM_NAME_SUBSCRIPTIONS",
+		Subjects: []string{"STREAM_NAME_SUBSCRIPTIONS.*"},
+		Storage:  nats.MemoryStorage,
+	})
+
+	cb := func(msg *nats.Msg) {}
+	pushSub, err := n.Subscribe("STREAM_NAME_SUBSCRIPTIONS.PUSH", cb, nats.Durable("push"), nats.ManualAck())
+	require.NoError(t, err)
+	queueSub, err := n.QueueSubscribe("STREAM_NAME_SUBSCRIPTIONS.QUEUE", "queue", cb, nats.ManualAck())
+	require.NoError(t, err)
+	pullSub, err := n.PullSubscribe("STREAM_NAME_SUBSCRIPTIONS.PULL", "pull")
+	require.NoError(t, err)
+
+	lister, ok := n.(SubscriptionLister)
+	require.True(t, ok)
+	assert.Equal(t, []SubscriptionInfo{
+		{Subject: "STREAM_NAME_SUBSCRIPTIONS.PUSH", Durable: "push", Subscription: pushSub},
+		{Subject: "STREAM_NAME_SUBSCRIPTIONS.QUEUE", Queue: "queue", Durable: "queue", Subscription: queueSub},
+		{Subject: "STREAM_NAME_SUBSCRIPTIONS.PULL", Durable: "pull", Subscription: pullSub},
+	}, lister.Subscriptions())
+
+	t.Run("subscribe again without reconnect", func(t *testing.T) {
+		sub, err := n.PullSubscribe("STREAM_NAME_SUBSCRIPTIONS.PULL", "pull")
+		require.NoError(t, err)
+		assert.NotSame(t, pullSub, sub)
+		require.NoError(t, sub.Unsubscribe())
+	})
+
+	t.Run("resubscribe while the clients are initialized again", func(t *testing.T) {
+		err := n.(*jsImpl).subs.reinitialize(func() error {
+			sub, err := n.PullSubscribe("STREAM_NAME_SUBSCRIPTIONS.PULL", "pull")
+			require.NoError(t, err)
+			assert.Same(t, pullSub, sub)
+
+			sub, err = n.Subscribe("STREAM_NAME_SUBSCRIPTIONS.PUSH", cb, nats.Durable("push"), nats.ManualAck())
+			require.NoError(t, err)
+			assert.Same(t, pushSub, sub)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("subscribe with another durable while the clients are initialized again", func(t *testing.T) {
+		err := n.(*jsImpl).subs.reinitialize(func() error {
+			sub, err := n.PullSubscribe("STREAM_NAME_SUBSCRIPTIONS.PULL", "other_pull")
+			require.NoError(t, err)
+			assert.NotSame(t, pullSub, sub)
+			return sub.Unsubscribe()
+		})
+		require.NoError(t, err)
+	})
+
+	require.NoError(t, pushSub.Unsubscribe())
+	assert.Eventually(t, func() bool {
+		return len(lister.Subscriptions()) == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestSubscriptions_Reconnect(t *testing.T) {
+	client := &resubscribingClient{}
+	n, err := NewNATSConnection(defaultURL, []JetStreamRegistrar{client})
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	// the client is initialized again once the server lost one of its streams
+	jsCtx, err := n.GetNATSConnection().JetStream()
+	require.NoError(t, err)
+	require.NoError(t, jsCtx.DeleteStream("STREAM_NAME_REGISTRY_LOST"))
+	require.NoError(t, n.GetNATSConnection().ForceReconnect())
+	assert.Eventually(t, func() bool {
+		subs, _ := client.registrations()
+		return len(subs) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	subs, errs := client.registrations()
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.Same(t, subs[0], subs[1])
+	assert.Len(t, n.(SubscriptionLister).Subscriptions(), 1)
+
+	t.Run("subscribe again after reconnect", func(t *testing.T) {
+		require.NoError(t, client.SubscribeJetStreamEvent())
+		subs, _ := client.registrations()
+		assert.NotSame(t, subs[0], subs[2])
+		require.NoError(t, subs[2].Unsubscribe())
+	})
+}
@@ -0,0 +1,180 @@
+package ferstream
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// tracerName instrumentation name of the ferstream spans
+const tracerName = "github.com/kumparan/ferstream"
+
+// span attributes
+const (
+	attrMessagingSystem      = attribute.Key("messaging.system")
+	attrMessagingDestination = attribute.Key("messaging.destination.name")
+	attrMessagingOperation   = attribute.Key("messaging.operation.type")
+	attrStream               = attribute.Key("messaging.nats.stream")
+	attrStreamSequence       = attribute.Key("messaging.nats.stream_sequence")
+	attrDeliveryCount        = attribute.Key("messaging.nats.delivery_count")
+	attrEventID              = attribute.Key("ferstream.event.id")
+	attrAttempt              = attribute.Key("ferstream.attempt")
+)
+
+type (
+	// TracingOption optional configuration of WithTracing and WithPublishTracing
+	TracingOption func(*tracing)
+
+	tracing struct {
+		tracerProvider trace.TracerProvider
+		propagator     propagation.TextMapPropagator
+		tracer         trace.Tracer
+	}
+
+	// headerCarrier adapts nats.Header into propagation.TextMapCarrier,
+	// unlike propagation.HeaderCarrier the keys are not canonicalized so they match HeaderTraceParent and HeaderTraceState
+	headerCarrier nats.Header
+)
+
+// noopTracing is used without WithTracing, the trace context is still propagated but no span is recorded
+var noopTracing = newTracing(WithTracerProvider(noop.NewTracerProvider()))
+
+// WithTracerProvider sets the tracer provider, default to the global otel tracer provider
+func WithTracerProvider(tp trace.TracerProvider) TracingOption {
+	return func(t *tracing) {
+		t.tracerProvider = tp
+	}
+}
+
+// WithPropagator sets the propagator, default to the W3C trace context propagator
+func WithPropagator(p propagation.TextMapPropagator) TracingOption {
+	return func(t *tracing) {
+		t.propagator = p
+	}
+}
+
+// WithTracing extracts the trace context from the message headers and starts a consumer span covering the delivery:
+// parsing, every attempt as a child span, the ack and the error handler. The handler's context carries the span.
+func WithTracing(opts ...TracingOption) HandlerOption {
+	t := newTracing(opts...)
+	return func(o *handlerOptions) {
+		o.tracing = t
+	}
+}
+
+// WithPublishTracing starts a producer span on PublishMsgWithContext, whose trace context is injected into the message headers
+func WithPublishTracing(opts ...TracingOption) ConnectionOption {
+	t := newTracing(opts...)
+	return func(o *connectionOptions) {
+		o.tracing = t
+	}
+}
+
+// InjectTraceContext injects the trace context of ctx into the message headers with the W3C trace context propagator
+func InjectTraceContext(ctx context.Context, msg *nats.Msg) {
+	noopTracing.inject(ctx, msg)
+}
+
+// ExtractTraceContext returns ctx with the trace context of the message headers, see InjectTraceContext
+func ExtractTraceContext(ctx context.Context, msg *nats.Msg) context.Context {
+	return noopTracing.propagator.Extract(ctx, headerCarrier(msg.Header))
+}
+
+func newTracing(opts ...TracingOption) *tracing {
+	t := &tracing{
+		tracerProvider: otel.GetTracerProvider(),
+		propagator:     propagation.TraceContext{},
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	t.tracer = t.tracerProvider.Tracer(tracerName)
+	return t
+}
+
+func (t *tracing) inject(ctx context.Context, msg *nats.Msg) {
+	if msg.Header == nil {
+		msg.Header = nats.Header{}
+	}
+	t.propagator.Inject(ctx, headerCarrier(msg.Header))
+}
+
+// startPublish starts the producer span and injects its trace context into the message headers
+func (t *tracing) startPublish(ctx context.Context, msg *nats.Msg) trace.Span {
+	ctx, span := t.tracer.Start(ctx, msg.Subject+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attrMessagingSystem.String("nats"),
+			attrMessagingDestination.String(msg.Subject),
+			attrMessagingOperation.String("publish"),
+		))
+	t.inject(ctx, msg)
+	return span
+}
+
+// startProcess starts the consumer span as a child of the trace context of the message headers
+func (t *tracing) startProcess(ctx context.Context, msg *nats.Msg) (context.Context, trace.Span) {
+	ctx = t.propagator.Extract(ctx, headerCarrier(msg.Header))
+	return t.tracer.Start(ctx, msg.Subject+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attrMessagingSystem.String("nats"),
+			attrMessagingDestination.String(msg.Subject),
+			attrMessagingOperation.String("process"),
+		))
+}
+
+// traceMetadata adds the message metadata and the event id to the delivery span
+func (d *delivery) traceMetadata() {
+	if d.meta != nil {
+		d.span.SetAttributes(
+			attrStream.String(d.meta.Stream),
+			attrStreamSequence.Int64(int64(d.meta.Sequence.Stream)),
+			attrDeliveryCount.Int64(int64(d.meta.NumDelivered)),
+		)
+	}
+	if msg, ok := d.payload.(*NatsEventMessage); ok && msg.NatsEvent != nil {
+		d.span.SetAttributes(attrEventID.String(eventID(msg.NatsEvent)))
+	}
+}
+
+func eventID(e *NatsEvent) string {
+	if e.GetIDString() != "" {
+		return e.GetIDString()
+	}
+	return strconv.FormatInt(e.GetID(), 10)
+}
+
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// Get :nodoc:
+func (c headerCarrier) Get(key string) string {
+	return nats.Header(c).Get(key)
+}
+
+// Set :nodoc:
+func (c headerCarrier) Set(key, value string) {
+	nats.Header(c).Set(key, value)
+}
+
+// Keys :nodoc:
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,189 @@
+package ferstream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("span %q is not recorded", name)
+	return tracetest.SpanStub{}
+}
+
+func spanAttributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+	for _, attr := range span.Attributes {
+		attrs[attr.Key] = attr.Value
+	}
+	return attrs
+}
+
+func spanEvents(span tracetest.SpanStub) []string {
+	events := make([]string, 0, len(span.Events))
+	for _, event := range span.Events {
+		if event.Name != "exception" {
+			events = append(events, event.Name)
+		}
+	}
+	return events
+}
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	n, err := NewNATSConnectionWithOptions(defaultURL, nil, WithPublishTracing(WithTracerProvider(tp)))
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	_, err = n.AddStream(&nats.StreamConfig{
+		Name:     "STREAM_NAME_TRACING",
+		Subjects: []string{"STREAM_NAME_TRACING.*"},
+		Storage:  nats.FileStorage,
+	})
+	require.NoError(t, err)
+
+	publish := func(ctx context.Context, subject string) *nats.PubAck {
+		msg, err := NewNatsEventMessage().WithEvent(&NatsEvent{ID: 1232, UserID: 21}).BuildMsg(subject)
+		require.NoError(t, err)
+		ack, err := n.PublishMsgWithContext(ctx, msg)
+		require.NoError(t, err)
+		return ack
+	}
+
+	t.Run("retry and ack", func(t *testing.T) {
+		exporter.Reset()
+		subject := "STREAM_NAME_TRACING.RETRY"
+		handlerSpanCh := make(chan trace.SpanContext, 2)
+		msgHandler := func(ctx context.Context, _ MessageParser) error {
+			handlerSpanCh <- trace.SpanContextFromContext(ctx)
+			if len(handlerSpanCh) == 1 {
+				return assert.AnError
+			}
+			return nil
+		}
+
+		sub, err := n.Subscribe(subject, NewMessageHandler(NewNatsEventMessage(), msgHandler,
+			WithRetryPolicy(NewScheduleRetryPolicy(time.Millisecond, time.Millisecond)),
+			WithTracing(WithTracerProvider(tp))),
+			nats.Durable("tracing_retry"), nats.ManualAck(), nats.DeliverNew())
+		require.NoError(t, err)
+		defer func() { _ = sub.Unsubscribe() }()
+
+		ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+		ack := publish(ctx, subject)
+		parent.End()
+
+		assert.Eventually(t, func() bool {
+			for _, span := range exporter.GetSpans() {
+				if span.Name == subject+" process" {
+					return true
+				}
+			}
+			return false
+		}, 5*time.Second, 10*time.Millisecond)
+
+		spans := exporter.GetSpans()
+		publishSpan := findSpan(t, spans, subject+" publish")
+		processSpan := findSpan(t, spans, subject+" process")
+		assert.Equal(t, parent.SpanContext().SpanID(), publishSpan.Parent.SpanID())
+		assert.Equal(t, trace.SpanKindProducer, publishSpan.SpanKind)
+		assert.Equal(t, publishSpan.SpanContext.SpanID(), processSpan.Parent.SpanID())
+		assert.Equal(t, parent.SpanContext().TraceID(), processSpan.SpanContext.TraceID())
+		assert.Equal(t, trace.SpanKindConsumer, processSpan.SpanKind)
+		assert.Equal(t, codes.Unset, processSpan.Status.Code)
+		assert.Equal(t, []string{"ack"}, spanEvents(processSpan))
+
+		attrs := spanAttributes(processSpan)
+		assert.Equal(t, subject, attrs[attrMessagingDestination].AsString())
+		assert.Equal(t, "STREAM_NAME_TRACING", attrs[attrStream].AsString())
+		assert.Equal(t, int64(ack.Sequence), attrs[attrStreamSequence].AsInt64())
+		assert.Equal(t, int64(1), attrs[attrDeliveryCount].AsInt64())
+		assert.Equal(t, "1232", attrs[attrEventID].AsString())
+
+		var attempts []tracetest.SpanStub
+		for _, span := range spans {
+			if span.Name == "attempt" {
+				attempts = append(attempts, span)
+			}
+		}
+		require.Len(t, attempts, 2)
+		for i, attempt := range attempts {
+			assert.Equal(t, processSpan.SpanContext.SpanID(), attempt.Parent.SpanID())
+			assert.Equal(t, int64(i+1), spanAttributes(attempt)[attrAttempt].AsInt64())
+		}
+		assert.Equal(t, codes.Error, attempts[0].Status.Code)
+		assert.Equal(t, codes.Unset, attempts[1].Status.Code)
+
+		// the handler's context carries the attempt span
+		assert.Equal(t, attempts[0].SpanContext.SpanID(), (<-handlerSpanCh).SpanID())
+	})
+
+	t.Run("give up", func(t *testing.T) {
+		exporter.Reset()
+		subject := "STREAM_NAME_TRACING.GIVE_UP"
+		msgHandler := func(_ context.Context, _ MessageParser) error {
+			return Permanent(assert.AnError)
+		}
+		errHandler := func(_ context.Context, _ MessageParser) error {
+			return nil
+		}
+
+		sub, err := n.Subscribe(subject, NewMessageHandler(NewNatsEventMessage(), msgHandler,
+			WithErrorHandler(errHandler),
+			WithTracing(WithTracerProvider(tp))),
+			nats.Durable("tracing_give_up"), nats.ManualAck(), nats.DeliverNew())
+		require.NoError(t, err)
+		defer func() { _ = sub.Unsubscribe() }()
+
+		publish(context.Background(), subject)
+
+		assert.Eventually(t, func() bool {
+			for _, span := range exporter.GetSpans() {
+				if span.Name == subject+" process" {
+					return true
+				}
+			}
+			return false
+		}, 5*time.Second, 10*time.Millisecond)
+
+		spans := exporter.GetSpans()
+		processSpan := findSpan(t, spans, subject+" process")
+		assert.Equal(t, codes.Error, processSpan.Status.Code)
+		assert.Equal(t, []string{"term"}, spanEvents(processSpan))
+
+		errHandlerSpan := findSpan(t, spans, "error handler")
+		assert.Equal(t, processSpan.SpanContext.SpanID(), errHandlerSpan.Parent.SpanID())
+	})
+}
+
+func TestInjectTraceContext(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "parent")
+	defer span.End()
+
+	msg := &nats.Msg{Subject: "SUBJECT"}
+	InjectTraceContext(ctx, msg)
+	assert.NotEmpty(t, msg.Header.Get(HeaderTraceParent))
+
+	extracted := trace.SpanContextFromContext(ExtractTraceContext(context.Background(), msg))
+	assert.Equal(t, span.SpanContext().TraceID(), extracted.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), extracted.SpanID())
+	assert.True(t, extracted.IsRemote())
+}
@@ -0,0 +1,42 @@
+package ferstream
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+)
+
+// TypedMessageHandler a ContextMessageHandler which receives the concrete payload type
+type TypedMessageHandler[T MessageParser] func(ctx context.Context, payload T) (err error)
+
+// NewTypedHandler same as NewMessageHandler, but a fresh payload is built with newFn for every delivery,
+// so it is safe for concurrent deliveries and no field leaks from the previous message.
+// The handler receives the concrete payload type, e.g.
+//
+//	ferstream.NewTypedHandler(ferstream.NewNatsEventMessage, func(ctx context.Context, msg *ferstream.NatsEventMessage) error {...})
+func NewTypedHandler[T MessageParser](newFn func() T, msgHandler TypedMessageHandler[T], opts ...HandlerOption) nats.MsgHandler {
+	newPayload := func() MessageParser {
+		return newFn()
+	}
+	return newMessageHandler(newPayload, msgHandler.untyped(), opts...).handle
+}
+
+// WithTypedErrorHandler same as WithErrorHandler, but the error handler receives the concrete payload type.
+// A permanent ErrCastingPayloadToStruct is returned when the payload is not T.
+func WithTypedErrorHandler[T MessageParser](errHandler TypedMessageHandler[T]) HandlerOption {
+	return WithErrorHandler(errHandler.untyped())
+}
+
+// untyped adapts TypedMessageHandler into ContextMessageHandler
+func (h TypedMessageHandler[T]) untyped() ContextMessageHandler {
+	if h == nil {
+		return nil
+	}
+	return func(ctx context.Context, payload MessageParser) error {
+		typedPayload, ok := payload.(T)
+		if !ok {
+			return Permanent(ErrCastingPayloadToStruct)
+		}
+		return h(ctx, typedPayload)
+	}
+}
@@ -0,0 +1,93 @@
+package ferstream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTypedHandler(t *testing.T) {
+	n, err := NewNATSConnection(defaultURL, nil)
+	require.NoError(t, err)
+	defer SafeClose(n)
+
+	_, err = n.AddStream(&nats.StreamConfig{
+		Name:     "STREAM_NAME_TYPED_HANDLER",
+		Subjects: []string{"STREAM_NAME_TYPED_HANDLER.*"},
+		Storage:  nats.FileStorage,
+	})
+	require.NoError(t, err)
+
+	t.Run("fresh payload for every delivery", func(t *testing.T) {
+		subject := "STREAM_NAME_TYPED_HANDLER.SUCCESS"
+		receiverCh := make(chan *NatsEventMessage, 2)
+		msgHandler := func(_ context.Context, msg *NatsEventMessage) error {
+			receiverCh <- msg
+			return nil
+		}
+
+		sub, err := n.Subscribe(subject, NewTypedHandler(NewNatsEventMessage, msgHandler),
+			nats.Durable("typed_handler_success"), nats.ManualAck(), nats.DeliverNew())
+		require.NoError(t, err)
+		defer func() { _ = sub.Unsubscribe() }()
+
+		for _, id := range []int64{1, 2} {
+			msgBytes, err := NewNatsEventMessage().WithEvent(&NatsEvent{ID: id, UserID: 21}).Build()
+			require.NoError(t, err)
+			_, err = n.Publish(subject, msgBytes)
+			require.NoError(t, err)
+		}
+
+		msg1 := <-receiverCh
+		msg2 := <-receiverCh
+		assert.NotSame(t, msg1, msg2)
+		assert.Equal(t, int64(1), msg1.NatsEvent.GetID())
+		assert.Equal(t, int64(2), msg2.NatsEvent.GetID())
+		assert.Equal(t, subject, msg2.NatsEvent.GetSubject())
+	})
+
+	t.Run("typed error handler", func(t *testing.T) {
+		subject := "STREAM_NAME_TYPED_HANDLER.ERROR"
+		msgHandler := func(_ context.Context, _ *NatsEventMessage) error {
+			return assert.AnError
+		}
+		errHandlerCh := make(chan *NatsEventMessage, 1)
+		errHandler := func(_ context.Context, msg *NatsEventMessage) error {
+			errHandlerCh <- msg
+			return nil
+		}
+
+		handler := NewTypedHandler(NewNatsEventMessage, msgHandler,
+			WithRetryPolicy(NewScheduleRetryPolicy(time.Millisecond)),
+			WithTypedErrorHandler(errHandler))
+		sub, err := n.Subscribe(subject, handler, nats.Durable("typed_handler_error"), nats.ManualAck(), nats.DeliverNew())
+		require.NoError(t, err)
+		defer func() { _ = sub.Unsubscribe() }()
+
+		_, err = n.Publish(subject, newTestNatsEventMessage(t))
+		require.NoError(t, err)
+
+		select {
+		case msg := <-errHandlerCh:
+			assert.Equal(t, int64(1232), msg.NatsEvent.GetID())
+		case <-time.After(5 * time.Second):
+			t.Fatal("error handler is not called")
+		}
+	})
+}
+
+func TestTypedMessageHandler_untyped(t *testing.T) {
+	handler := TypedMessageHandler[*NatsEventMessage](func(_ context.Context, _ *NatsEventMessage) error {
+		return nil
+	}).untyped()
+
+	assert.NoError(t, handler(context.Background(), NewNatsEventMessage()))
+
+	err := handler(context.Background(), &NatsEventAuditLogMessage{})
+	assert.True(t, IsPermanent(err))
+	assert.ErrorIs(t, err, ErrCastingPayloadToStruct)
+}
@@ -0,0 +1,225 @@
+package ferstream
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+type (
+	// OrderingKeyFunc returns the ordering key of a message, messages with the same key are processed in order by the same worker
+	OrderingKeyFunc func(msg *nats.Msg) string
+
+	// WorkerPoolOption optional configuration of WorkerPool
+	WorkerPoolOption func(*WorkerPool)
+
+	// WorkerPool processes the messages of a subscription on multiple worker goroutines,
+	// use WorkerPool.Handle as the subscription's nats.MsgHandler
+	WorkerPool struct {
+		handler     nats.MsgHandler
+		workers     int
+		queueSize   int
+		orderingKey OrderingKeyFunc
+		queues      []chan queuedMsg
+
+		mu      sync.RWMutex
+		closed  bool
+		closing chan struct{}
+		senders sync.WaitGroup
+		running sync.WaitGroup
+	}
+
+	// queuedMsg a message waiting for a worker, done is called once it is handled
+	queuedMsg struct {
+		msg *nats.Msg
+		// delivered the time the message is given to Handle, its AckWait started before it waited for a worker
+		delivered time.Time
+		// consumed the jetstream.Msg of a message given by Consume, its handler finds it again in the worker
+		consumed *consumedMsg
+		done     func()
+	}
+)
+
+// queuedDeliveries the delivery time of every queued message being handled by a worker,
+// the message handlers find it by the message so the time spent in the queue counts in the AckWait
+var queuedDeliveries sync.Map
+
+// WithQueueSize sets the max number of messages waiting for a worker, default to the number of workers.
+// Handle blocks once the queue is full, so the subscription stops taking messages until a worker is free.
+// With WithOrderingKey, the size is split between the queues of the workers, rounded up to at least 1 message per worker.
+func WithQueueSize(size int) WorkerPoolOption {
+	return func(p *WorkerPool) {
+		p.queueSize = size
+	}
+}
+
+// WithOrderingKey processes messages with the same key in order on the same worker
+func WithOrderingKey(fn OrderingKeyFunc) WorkerPoolOption {
+	return func(p *WorkerPool) {
+		p.orderingKey = fn
+	}
+}
+
+// NatsEventIDKey OrderingKeyFunc of NatsEventMessage by NatsEvent's ID, or IDString when ID is empty
+func NatsEventIDKey(msg *nats.Msg) string {
+	eventMsg, err := ParseNatsEventMessageFromBytes(msg.Data)
+	if err != nil || eventMsg.NatsEvent == nil {
+		return ""
+	}
+
+	if eventMsg.NatsEvent.GetID() > 0 {
+		return strconv.FormatInt(eventMsg.NatsEvent.GetID(), 10)
+	}
+	return eventMsg.NatsEvent.GetIDString()
+}
+
+// NewWorkerPool starts a pool of workers calling handler, e.g. a handler from NewTypedHandler.
+// Call Shutdown before SafeClose, so the in-flight messages are finished before the connection is drained.
+func NewWorkerPool(workers int, handler nats.MsgHandler, opts ...WorkerPoolOption) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+
+	p := &WorkerPool{
+		handler:   handler,
+		workers:   workers,
+		queueSize: workers,
+		closing:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.start()
+	return p
+}
+
+func (p *WorkerPool) start() {
+	if p.orderingKey == nil {
+		// a single queue shared by every worker
+		queue := make(chan queuedMsg, p.queueSize)
+		p.queues = []chan queuedMsg{queue}
+		for i := 0; i < p.workers; i++ {
+			p.runWorker(queue)
+		}
+		return
+	}
+
+	// a queue per worker, so the messages with the same key are processed in order
+	queueSize := max(1, (p.queueSize+p.workers-1)/p.workers)
+	for i := 0; i < p.workers; i++ {
+		queue := make(chan queuedMsg, queueSize)
+		p.queues = append(p.queues, queue)
+		p.runWorker(queue)
+	}
+}
+
+func (p *WorkerPool) runWorker(queue chan queuedMsg) {
+	p.running.Add(1)
+	go func() {
+		defer p.running.Done()
+		for queued := range queue {
+			queued.handle(p.handler)
+		}
+	}()
+}
+
+// Handle queues the message to a worker, it blocks while the queue is full.
+// The message is nak-ed when the pool is shut down, so the server redelivers it.
+// A queued message counts as a running message handler of its subscription, so Shutdown waits for it.
+func (p *WorkerPool) Handle(msg *nats.Msg) {
+	delivered := time.Now()
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		nakClosedPool(msg)
+		return
+	}
+	p.senders.Add(1)
+	p.mu.RUnlock()
+	defer p.senders.Done()
+
+	queued := queuedMsg{msg: msg, delivered: delivered, done: startHandling(msg)}
+	queued.consumed, _ = getConsumedMsg(msg)
+	select {
+	case p.queue(msg) <- queued:
+	case <-p.closing:
+		nakClosedPool(msg)
+		queued.done()
+	}
+}
+
+// handle calls handler with the queued message, which finds the delivery time by deliveredAt
+// and the jetstream.Msg of a message given by Consume by getConsumedMsg
+func (q queuedMsg) handle(handler nats.MsgHandler) {
+	queuedDeliveries.Store(q.msg, q.delivered)
+	defer queuedDeliveries.Delete(q.msg)
+	if q.consumed != nil {
+		// a copy, so the Consume callback returning meanwhile does not delete it
+		consumed := *q.consumed
+		consumedMsgs.Store(q.msg, &consumed)
+		defer consumedMsgs.CompareAndDelete(q.msg, &consumed)
+	}
+	defer q.done()
+
+	handler(q.msg)
+}
+
+// deliveredAt returns the time msg is delivered, before it waited for a worker of a WorkerPool
+func deliveredAt(msg *nats.Msg) time.Time {
+	if delivered, ok := queuedDeliveries.Load(msg); ok {
+		return delivered.(time.Time)
+	}
+	return time.Now()
+}
+
+func (p *WorkerPool) queue(msg *nats.Msg) chan queuedMsg {
+	if len(p.queues) == 1 {
+		return p.queues[0]
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(p.orderingKey(msg)))
+	return p.queues[h.Sum32()%uint32(len(p.queues))]
+}
+
+// Shutdown stops taking new messages and waits until the queued and in-flight messages are processed or ctx is done
+func (p *WorkerPool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.closing)
+
+		go func() {
+			p.senders.Wait()
+			for _, queue := range p.queues {
+				close(queue)
+			}
+		}()
+	}
+	p.mu.Unlock()
+
+	doneCh := make(chan struct{})
+	go func() {
+		p.running.Wait()
+		close(doneCh)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-doneCh:
+		return nil
+	}
+}
+
+func nakClosedPool(msg *nats.Msg) {
+	err := getMsgAcker(msg).Nak()
+	if err != nil {
+		getLogger(nil).log(LogEventAckFailed, err, LogFields{"subject": msg.Subject})
+	}
+}
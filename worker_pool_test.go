@@ -0,0 +1,149 @@
+package ferstream
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerPool(t *testing.T) {
+	t.Run("process concurrently", func(t *testing.T) {
+		var running, maxRunning int32
+		var wg sync.WaitGroup
+		handler := func(_ *nats.Msg) {
+			defer wg.Done()
+			curr := atomic.AddInt32(&running, 1)
+			for {
+				prev := atomic.LoadInt32(&maxRunning)
+				if curr <= prev || atomic.CompareAndSwapInt32(&maxRunning, prev, curr) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		}
+
+		pool := NewWorkerPool(4, handler, WithQueueSize(8))
+		wg.Add(8)
+		for i := 0; i < 8; i++ {
+			pool.Handle(&nats.Msg{Subject: "TEST"})
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(4), atomic.LoadInt32(&maxRunning))
+		require.NoError(t, pool.Shutdown(context.Background()))
+	})
+
+	t.Run("ordered by key", func(t *testing.T) {
+		var mu sync.Mutex
+		processed := map[string][]int{}
+		handler := func(msg *nats.Msg) {
+			seq, _ := strconv.Atoi(string(msg.Data))
+			time.Sleep(time.Duration(10-seq) * time.Millisecond)
+			mu.Lock()
+			processed[msg.Subject] = append(processed[msg.Subject], seq)
+			mu.Unlock()
+		}
+
+		pool := NewWorkerPool(4, handler, WithQueueSize(20), WithOrderingKey(func(msg *nats.Msg) string {
+			return msg.Subject
+		}))
+		for seq := 0; seq < 10; seq++ {
+			for _, key := range []string{"A", "B", "C"} {
+				pool.Handle(&nats.Msg{Subject: key, Data: []byte(strconv.Itoa(seq))})
+			}
+		}
+		require.NoError(t, pool.Shutdown(context.Background()))
+
+		expected := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+		assert.Equal(t, expected, processed["A"])
+		assert.Equal(t, expected, processed["B"])
+		assert.Equal(t, expected, processed["C"])
+	})
+
+	t.Run("queue size split between ordered workers", func(t *testing.T) {
+		orderingKey := WithOrderingKey(func(msg *nats.Msg) string {
+			return msg.Subject
+		})
+		for size, perWorker := range map[int]int{2: 1, 4: 1, 5: 2, 8: 2} {
+			pool := NewWorkerPool(4, func(_ *nats.Msg) {}, WithQueueSize(size), orderingKey)
+			for _, queue := range pool.queues {
+				assert.Equal(t, perWorker, cap(queue), "queue size %d", size)
+			}
+			require.NoError(t, pool.Shutdown(context.Background()))
+		}
+	})
+
+	t.Run("deadline counts the time spent in the queue", func(t *testing.T) {
+		deadlineCh := make(chan time.Time, 2)
+		handler := NewMessageHandler(NewNatsEventMessage(), func(ctx context.Context, _ MessageParser) error {
+			deadline, _ := ctx.Deadline()
+			deadlineCh <- deadline
+			time.Sleep(200 * time.Millisecond)
+			return nil
+		})
+
+		pool := NewWorkerPool(1, handler, WithQueueSize(2))
+		msgBytes := newTestNatsEventMessage(t)
+		pool.Handle(&nats.Msg{Subject: "TEST", Data: msgBytes})
+		delivered := time.Now()
+		pool.Handle(&nats.Msg{Subject: "TEST", Data: msgBytes})
+		require.NoError(t, pool.Shutdown(context.Background()))
+
+		<-deadlineCh
+		assert.WithinDuration(t, delivered.Add(defaultAckWait), <-deadlineCh, 50*time.Millisecond)
+	})
+
+	t.Run("shutdown waits for in-flight messages", func(t *testing.T) {
+		var processed int32
+		handler := func(_ *nats.Msg) {
+			time.Sleep(50 * time.Millisecond)
+			atomic.AddInt32(&processed, 1)
+		}
+
+		pool := NewWorkerPool(2, handler, WithQueueSize(2))
+		for i := 0; i < 4; i++ {
+			pool.Handle(&nats.Msg{Subject: "TEST"})
+		}
+		require.NoError(t, pool.Shutdown(context.Background()))
+		assert.Equal(t, int32(4), atomic.LoadInt32(&processed))
+
+		// messages after shutdown are not processed
+		pool.Handle(&nats.Msg{Subject: "TEST"})
+		assert.Equal(t, int32(4), atomic.LoadInt32(&processed))
+	})
+
+	t.Run("shutdown deadline exceeded", func(t *testing.T) {
+		blockCh := make(chan struct{})
+		pool := NewWorkerPool(1, func(_ *nats.Msg) {
+			<-blockCh
+		})
+		pool.Handle(&nats.Msg{Subject: "TEST"})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.Equal(t, context.DeadlineExceeded, pool.Shutdown(ctx))
+
+		close(blockCh)
+		require.NoError(t, pool.Shutdown(context.Background()))
+	})
+}
+
+func TestNatsEventIDKey(t *testing.T) {
+	msgBytes, err := NewNatsEventMessage().WithEvent(&NatsEvent{ID: 123, UserID: 21}).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "123", NatsEventIDKey(&nats.Msg{Data: msgBytes}))
+
+	msgBytes, err = NewNatsEventMessage().WithEvent(&NatsEvent{IDString: "abc", UserID: 21}).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "abc", NatsEventIDKey(&nats.Msg{Data: msgBytes}))
+
+	assert.Equal(t, "", NatsEventIDKey(&nats.Msg{Data: []byte("not a json")}))
+}